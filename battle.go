@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/typechart"
+)
+
+// Port used by battle host, and by battle join when the address has none.
+const defaultBattlePort = 7777
+
+var errNobodyToAsk = errors.New("battles need someone to choose the moves, run the Pokedex interactively")
+
+// battleTeam is the party, ready for a battle. Pokemon over levelCap fight at levelCap.
+func battleTeam(party []pc.Pokemon, levelCap int) []battle.Pokemon {
+	team := []battle.Pokemon{}
+	for _, p := range party {
+		level := p.Level
+		if level == 0 {
+			level = pc.DefaultLevel
+		}
+		level = min(level, levelCap)
+		stats := map[string]int{}
+		for _, s := range p.Stats {
+			stats[s.Stat.Name] = s.BaseStat
+		}
+		team = append(team, battle.Pokemon{
+			Name:      p.Name,
+			Nickname:  p.Nickname,
+			Level:     level,
+			Types:     p.TypeNames(),
+			BaseStats: stats,
+		})
+	}
+	return team
+}
+
+// playerTeam is the team of the trainer for a battle where they choose the moves.
+func (config *Config) playerTeam() ([]battle.Pokemon, error) {
+	if config.readLine == nil {
+		return nil, errNobodyToAsk
+	}
+	team := battleTeam(config.save.Storage.Party, config.levelCap())
+	if len(team) == 0 {
+		return nil, battle.ErrEmptyTeam
+	}
+	return team, nil
+}
+
+// newBattleRand is where the luck of a battle comes from.
+func newBattleRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// terminalPlayer asks the user what to do.
+type terminalPlayer struct {
+	config *Config
+}
+
+func (p terminalPlayer) Show(events []string) error {
+	for _, e := range events {
+		fmt.Fprintln(p.config.out, e)
+	}
+	return nil
+}
+
+func (p terminalPlayer) Choose(v battle.View) (battle.Action, error) {
+	w := p.config.out
+	me := v.Me()
+
+	fmt.Fprintf(w, "\nTurn %d: your %s (Lv. %d, %d/%d HP) vs %s's %s (Lv. %d, %d/%d HP)\n",
+		v.Turn, me.DisplayName(), me.Level, me.HP, me.MaxHP, v.Opponent, v.Foe.DisplayName(), v.Foe.Level, v.Foe.HP, v.Foe.MaxHP)
+	for i, m := range me.Moves {
+		fmt.Fprintf(w, "  %d. %-16s %-9s x%g\n", i+1, m.Name, m.Type, typechart.Effectiveness(m.Type, v.Foe.Types...))
+	}
+	team := []string{}
+	for i, f := range v.Team {
+		team = append(team, fmt.Sprintf("%d. %s %d/%d", i+1, f.DisplayName(), f.HP, f.MaxHP))
+	}
+	fmt.Fprintf(w, "  Team: %s\n", strings.Join(team, ", "))
+
+	for {
+		line, err := p.config.readLine(fmt.Sprintf("What will %s do? (move, switch <n> or forfeit) > ", me.DisplayName()))
+		if err != nil {
+			return battle.Action{}, err
+		}
+		action, err := parseBattleAction(line, me.Moves)
+		if err == nil {
+			return action, nil
+		}
+		fmt.Fprintln(w, err)
+	}
+}
+
+// parseBattleAction reads a choice: a move by number or name, switch <n> or forfeit.
+func parseBattleAction(line string, moves []battle.Move) (battle.Action, error) {
+	words := strings.Fields(strings.ToLower(line))
+	if len(words) == 0 {
+		return battle.Action{}, fmt.Errorf("choose a move by its number or name, switch <n> or forfeit")
+	}
+
+	switch words[0] {
+	case "forfeit", "run":
+		return battle.Action{Kind: battle.Forfeit}, nil
+	case "switch":
+		if len(words) != 2 {
+			return battle.Action{}, fmt.Errorf("usage: switch <n>")
+		}
+		n, err := strconv.Atoi(words[1])
+		if err != nil {
+			return battle.Action{}, fmt.Errorf("switch to which Pokemon of your team? (a number)")
+		}
+		return battle.Action{Kind: battle.Switch, Index: n - 1}, nil
+	}
+
+	if n, err := strconv.Atoi(words[0]); err == nil {
+		return battle.Action{Kind: battle.UseMove, Index: n - 1}, nil
+	}
+	for i, m := range moves {
+		if m.Name == words[0] {
+			return battle.Action{Kind: battle.UseMove, Index: i}, nil
+		}
+	}
+	return battle.Action{}, fmt.Errorf("unknown move %q", words[0])
+}
+
+type battleResult struct {
+	battle.Result
+	// Set when the stats could not be updated
+	Warning string `json:"warning,omitempty"`
+}
+
+func (r battleResult) Text(w io.Writer) {
+	if r.Won {
+		fmt.Fprintf(w, "You defeated %s in %d turns!\n", r.Opponent, r.Turns)
+	} else {
+		fmt.Fprintf(w, "You lost against %s.\n", r.Opponent)
+	}
+	if r.Warning != "" {
+		fmt.Fprintf(w, "(%s)\n", r.Warning)
+	}
+}
+
+func commandBattle(config *Config) (any, error) {
+	team, err := config.playerTeam()
+	if err != nil {
+		return nil, err
+	}
+	player := terminalPlayer{config}
+
+	switch strings.ToLower(config.args.Arg(0)) {
+	case "host":
+		port := defaultBattlePort
+		if value, ok := config.args.Flag("port"); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid port: %s", value)
+			}
+			port = n
+		}
+
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return nil, err
+		}
+		defer ln.Close()
+		fmt.Fprintf(config.out, "Waiting for a trainer on port %d...\n", ln.Addr().(*net.TCPAddr).Port)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		result, err := battle.Host(conn, config.trainer, team, player, newBattleRand())
+		if err != nil {
+			return nil, err
+		}
+		return config.recordBattle(result), nil
+
+	case "join":
+		addr := config.args.Arg(1)
+		if addr == "" {
+			return nil, fmt.Errorf("join who? usage: battle join <host[:port]>")
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, strconv.Itoa(defaultBattlePort))
+		}
+
+		conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to %s: %w", addr, err)
+		}
+		defer conn.Close()
+
+		result, err := battle.Join(conn, config.trainer, team, player)
+		if err != nil {
+			return nil, err
+		}
+		return config.recordBattle(result), nil
+	}
+
+	return nil, fmt.Errorf("unknown battle action %q (use host or join)", config.args.Arg(0))
+}
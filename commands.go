@@ -0,0 +1,302 @@
+package main
+
+import "github.com/neixir/pokedex/internal/registry"
+
+type command = registry.Command[*Config]
+
+// newRegistry registers every command of the REPL.
+func newRegistry() *registry.Registry[*Config] {
+	commands := registry.New[*Config]()
+
+	commands.MustRegister(
+		command{
+			Name:        "help",
+			Aliases:     []string{"?"},
+			Category:    "General",
+			Usage:       "help [command]",
+			Description: "Displays a help message", // "Lists all available commands",
+			Help:        "Without arguments lists all the commands. With a command name shows its usage, aliases and details.",
+			MaxArgs:     1,
+			Callback:    commandHelp,
+		},
+		command{
+			Name:        "exit",
+			Aliases:     []string{"quit", "q"},
+			Category:    "General",
+			Description: "Exit the Pokedex",
+			Callback:    commandExit,
+		},
+
+		// C2 L1 https://www.boot.dev/lessons/813eafe1-2e1d-42a0-b358-53e0f4d4fdc8
+		command{
+			Name:        "map",
+			Category:    "Exploration",
+			Usage:       "map [first|last]",
+			Description: "Displays a page of location areas in the Pokemon world",
+			Help: "Each call shows the next page of location areas. Use mapb to go back.\n" +
+				"Options:\n" +
+				"  --page=<n>   jump to a page\n" +
+				"  --limit=<n>  areas per page (default 20), starting again from the first page",
+			MaxArgs:  1,
+			Callback: commandMap,
+		},
+		command{
+			Name:        "mapb",
+			Category:    "Exploration",
+			Description: "Displays the previous page of location areas",
+			Callback:    commandMapB,
+		},
+
+		// C2 L3 https://www.boot.dev/lessons/e53abbb4-5d8a-4feb-ba08-828f03311e51
+		command{
+			Name:        "explore",
+			Aliases:     []string{"ex"},
+			Category:    "Exploration",
+			Usage:       "explore <area name>",
+			Description: "Lists all the pokemon located in an area",
+			Help:        "Every Pokemon found is marked as seen in your Pokedex.",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandExplore,
+		},
+
+		// C2 L4 https://www.boot.dev/lessons/ed962683-cb2d-4989-99e9-5cfa144810b5
+		command{
+			Name:        "catch",
+			Category:    "Pokemon",
+			Usage:       "catch <pokemon>",
+			Description: "Catching Pokemon adds them to the user's Pokedex",
+			Help:        "The higher the base experience of a Pokemon, the harder it is to catch.\nCaught Pokemon join your party, or go to the PC if the party is full.",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandCatch,
+		},
+
+		// C2 L5 https://www.boot.dev/lessons/0911b406-0b43-4bfe-b60c-177d859093e1
+		command{
+			Name:        "inspect",
+			Category:    "Pokemon",
+			Usage:       "inspect <pokemon>",
+			Description: "Prints the name, height, weight, stats and type(s) of the Pokemon",
+			Help:        "With --graph the stats are drawn as bars, with their total and, if you have\nsynced the Pokemon, how they rank against all of them.",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandInspect,
+		},
+
+		// C3 L1 https://www.boot.dev/lessons/104a68ca-cea7-42ef-9321-fb8270000db2
+		command{
+			Name:        "pokedex",
+			Aliases:     []string{"dex"},
+			Category:    "Pokemon",
+			Usage:       "pokedex [caught|seen|completion]",
+			Description: "Lists caught (or seen) Pokemon by Dex number",
+			Help: "Options:\n" +
+				"  --type=<type>      only Pokemon of that type\n" +
+				"  --region=<pokedex> use a regional Pokedex (kanto, original-johto, hoenn...)\n" +
+				"\"pokedex completion\" shows how much of each generation you have seen and caught.",
+			MaxArgs:  1,
+			Callback: commandPokedex,
+		},
+
+		command{
+			Name:        "compare",
+			Aliases:     []string{"vs"},
+			Category:    "Pokemon",
+			Usage:       "compare <a> <b> [c...]",
+			Description: "Compares the stats and types of Pokemon, caught or not",
+			Help:        "The best value of each row is marked with *. The matchups show how well\neach Pokemon hits the others with the best of its types.",
+			MinArgs:     2,
+			MaxArgs:     -1,
+			Callback:    commandCompare,
+		},
+		command{
+			Name:        "sprite",
+			Category:    "Pokemon",
+			Usage:       "sprite <pokemon>",
+			Description: "Draws the sprite of a Pokemon in the terminal",
+			Help: "Options:\n" +
+				"  --shiny             the shiny colours\n" +
+				"  --back              seen from behind\n" +
+				"  --generation=<gen>  the sprite of an older game: i (Red/Blue), ii (Crystal), iii (Emerald)... vii\n" +
+				"  --ascii, --color    force grayscale ASCII or truecolor (by default, truecolor if COLORTERM says so)",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Callback: commandSprite,
+		},
+		command{
+			Name:        "search",
+			Aliases:     []string{"find"},
+			Category:    "Pokemon",
+			Usage:       "search <query>",
+			Description: "Finds Pokemon, areas, moves and items by name, even misspelled",
+			Help: "Options:\n" +
+				"  --kind=<kind>  only pokemon, area, move or item\n" +
+				"  --limit=<n>    how many results to show (default 10)\n" +
+				"  --refresh      download the lists of names again",
+			MinArgs:  1,
+			MaxArgs:  -1,
+			Callback: commandSearch,
+		},
+
+		command{
+			Name:        "party",
+			Category:    "Storage",
+			Description: "Lists the Pokemon in your party",
+			Callback:    commandParty,
+		},
+		command{
+			Name:        "deposit",
+			Category:    "Storage",
+			Usage:       "deposit <pokemon|slot>",
+			Description: "Moves a party Pokemon to the PC",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandDeposit,
+		},
+		command{
+			Name:        "withdraw",
+			Category:    "Storage",
+			Usage:       "withdraw <pokemon|slot>",
+			Description: "Moves a Pokemon from the current PC box to your party",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandWithdraw,
+		},
+		command{
+			Name:        "swap",
+			Category:    "Storage",
+			Usage:       "swap <a> <b>",
+			Description: "Swaps two party slots, or a party Pokemon with one in the PC",
+			MinArgs:     2,
+			MaxArgs:     2,
+			Callback:    commandSwap,
+		},
+		command{
+			Name:        "release",
+			Category:    "Storage",
+			Usage:       "release <pokemon|slot>",
+			Description: "Releases a Pokemon back into the wild",
+			MinArgs:     1,
+			MaxArgs:     1,
+			Callback:    commandRelease,
+		},
+		command{
+			Name:        "nickname",
+			Aliases:     []string{"rename"},
+			Category:    "Storage",
+			Usage:       "nickname <pokemon|slot> [nickname]",
+			Description: "Gives a nickname to one of your Pokemon",
+			Help:        "Quote nicknames with spaces: nickname pikachu \"Sir Sparks\"\nWithout a nickname, the current one is removed.",
+			MinArgs:     1,
+			MaxArgs:     2,
+			Callback:    commandNickname,
+		},
+		command{
+			Name:        "box",
+			Category:    "Storage",
+			Usage:       "box [n]",
+			Description: "Shows the contents of a PC box and makes it the current one",
+			MaxArgs:     1,
+			Callback:    commandBox,
+		},
+
+		command{
+			Name:        "trainer",
+			Category:    "Trainer",
+			Usage:       "trainer [list|new <name>|switch <name>]",
+			Description: "Shows your trainer, or creates and switches trainer profiles",
+			Help: "Every trainer has their own Pokemon, Pokedex, bag, location and stats.\n" +
+				"Without arguments shows the trainer being played, with their bag and stats.\n" +
+				"  trainer list           lists the trainers, * marks the active one\n" +
+				"  trainer new <name>     creates a trainer and starts playing as them\n" +
+				"  trainer switch <name>  saves the game and plays as another trainer",
+			MaxArgs:  2,
+			Callback: commandTrainer,
+		},
+		command{
+			Name:        "trade",
+			Category:    "Trainer",
+			Usage:       "trade [offer <your pokemon> <trainer> <their pokemon>|accept [id]|decline [id]|log]",
+			Description: "Trades Pokemon with the other trainers",
+			Help: "Without arguments lists the offers made by and to you.\n" +
+				"  trade offer <yours> <trainer> <theirs>  offers one of your Pokemon for one of theirs\n" +
+				"  trade accept [id]                       accepts an offer made to you (switch to that trainer first)\n" +
+				"  trade decline [id]                      declines an offer, or cancels one of yours\n" +
+				"  trade log                               everything that happened to every offer\n" +
+				"Only Pokemon in the party can be traded. Some of them evolve when traded!",
+			MaxArgs:  4,
+			Callback: commandTrade,
+		},
+		command{
+			Name:        "battle",
+			Category:    "Trainer",
+			Usage:       "battle host [--port=n] | battle join <host[:port]>",
+			Description: "Battles another trainer over the network",
+			Help: "One trainer hosts the battle and the other one joins it, with their parties.\n" +
+				"  battle host [--port=n]    waits for a trainer on port n (default 7777)\n" +
+				"  battle join <host[:port]> joins the battle hosted at that address\n" +
+				"Every turn choose a move by its number or name, switch <n> to another Pokemon or forfeit.",
+			MinArgs:  1,
+			MaxArgs:  2,
+			Callback: commandBattle,
+		},
+		command{
+			Name:        "challenge",
+			Category:    "Trainer",
+			Usage:       "challenge [area] [--difficulty=easy|normal|hard] [--size=n]",
+			Description: "Battles a trainer of the area",
+			Help: "Battles a trainer with a team of Pokemon found in the area, at their levels (by default the area explored last).\n" +
+				"  --difficulty=easy    the trainer uses any move\n" +
+				"  --difficulty=normal  the trainer uses the move that hurts the most (default)\n" +
+				"  --difficulty=hard    the trainer thinks a few turns ahead and switches Pokemon\n" +
+				"  --size=n             Pokemon in the trainer's team (default 3)",
+			MaxArgs:  1,
+			Callback: commandChallenge,
+		},
+		command{
+			Name:        "gym",
+			Category:    "Trainer",
+			Usage:       "gym [challenge]",
+			Description: "Shows or challenges the gym of the city you're in",
+			Help: "Shows the leader and the team of the gym in the location of the area explored last.\n" +
+				"  gym challenge  battles the leader to earn their badge\n" +
+				"The gyms of a region are challenged in order, and every badge raises the level your Pokemon fight at.",
+			MaxArgs:  1,
+			Callback: commandGym,
+		},
+		command{
+			Name:        "badges",
+			Category:    "Trainer",
+			Description: "Lists the badges of every region and the ones you have",
+			Callback:    commandBadges,
+		},
+		command{
+			Name:        "stats",
+			Category:    "Trainer",
+			Usage:       "stats [ball|species|area]",
+			Description: "Shows your catch rates, battles and achievements",
+			Help: "Everything you do is recorded: every Poke Ball thrown, area explored and battle.\n" +
+				"  stats          totals, catch rates by ball, species and area, and achievements\n" +
+				"  stats species  only the catch rates by species (or ball, or area)",
+			MaxArgs:  1,
+			Callback: commandStats,
+		},
+
+		command{
+			Name:        "sync",
+			Category:    "General",
+			Usage:       "sync [resource...]",
+			Description: "Downloads PokeAPI to use the Pokedex offline",
+			Help: "Resources: pokemon, pokemon-species, type, move and location-area (all of them by default).\n" +
+				"Only what is missing is downloaded, so an interrupted sync can be run again to resume it.\n" +
+				"Options:\n" +
+				"  --jobs=<n>  concurrent downloads (default 8)\n" +
+				"  --verify    check the downloaded files and download the damaged ones again",
+			MaxArgs:  -1,
+			Callback: commandSync,
+		},
+	)
+
+	return commands
+}
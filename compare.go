@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/typechart"
+)
+
+// compareStats are the rows of base stats, in the order of the games.
+var compareStats = []string{"hp", "attack", "defense", "special-attack", "special-defense", "speed"}
+
+type compareRow struct {
+	Label  string   `json:"label"`
+	Values []string `json:"values"`
+	// Index of the Pokemon with the highest value, -1 on a tie or when there is nothing to win
+	Winner int `json:"winner"`
+}
+
+type matchup struct {
+	Attacker   string  `json:"attacker"`
+	Defender   string  `json:"defender"`
+	Type       string  `json:"type"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type compareResult struct {
+	Pokemon  []string     `json:"pokemon"`
+	Rows     []compareRow `json:"rows"`
+	Matchups []matchup    `json:"matchups"`
+}
+
+func (r compareResult) Text(w io.Writer) {
+	header, rows := r.Table()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nType matchups (best attack type of each Pokemon):")
+	for _, m := range r.Matchups {
+		fmt.Fprintf(w, "  %s -> %s: %vx (%s)\n", m.Attacker, m.Defender, m.Multiplier, m.Type)
+	}
+}
+
+func (r compareResult) Table() ([]string, [][]string) {
+	header := append([]string{""}, r.Pokemon...)
+	rows := [][]string{}
+	for _, row := range r.Rows {
+		cells := []string{row.Label}
+		for i, value := range row.Values {
+			if i == row.Winner {
+				value += " *"
+			}
+			cells = append(cells, value)
+		}
+		rows = append(rows, cells)
+	}
+	return header, rows
+}
+
+// numericRow builds a row where the highest number wins.
+func numericRow(label string, numbers []int, format func(int) string) compareRow {
+	row := compareRow{Label: label, Values: []string{}, Winner: -1}
+	best := -1
+	for i, n := range numbers {
+		row.Values = append(row.Values, format(n))
+		switch {
+		case n > best:
+			best, row.Winner = n, i
+		case n == best:
+			row.Winner = -1
+		}
+	}
+	return row
+}
+
+func commandCompare(config *Config) (any, error) {
+	pokemons := []pokeapi.PokemonType{}
+	for _, ref := range config.args.Positional {
+		pokemon, err := config.lookupPokemon(ref)
+		if err != nil {
+			return nil, err
+		}
+		pokemons = append(pokemons, pokemon)
+	}
+
+	result := compareResult{Pokemon: []string{}, Rows: []compareRow{}, Matchups: []matchup{}}
+	types := [][]string{}
+	for _, p := range pokemons {
+		result.Pokemon = append(result.Pokemon, p.Name)
+		names := []string{}
+		for _, t := range p.Types {
+			names = append(names, t.Type.Name)
+		}
+		types = append(types, names)
+	}
+
+	totals := make([]int, len(pokemons))
+	for _, stat := range compareStats {
+		values := []int{}
+		for i, p := range pokemons {
+			value := 0
+			for _, s := range p.Stats {
+				if s.Stat.Name == stat {
+					value = s.BaseStat
+				}
+			}
+			values = append(values, value)
+			totals[i] += value
+		}
+		result.Rows = append(result.Rows, numericRow(stat, values, func(n int) string { return fmt.Sprint(n) }))
+	}
+	result.Rows = append(result.Rows, numericRow("total", totals, func(n int) string { return fmt.Sprint(n) }))
+
+	typesRow := compareRow{Label: "types", Values: []string{}, Winner: -1}
+	abilitiesRow := compareRow{Label: "abilities", Values: []string{}, Winner: -1}
+	heights, weights := []int{}, []int{}
+	for i, p := range pokemons {
+		typesRow.Values = append(typesRow.Values, strings.Join(types[i], "/"))
+		abilities := []string{}
+		for _, a := range p.Abilities {
+			abilities = append(abilities, a.Ability.Name)
+		}
+		abilitiesRow.Values = append(abilitiesRow.Values, strings.Join(abilities, ", "))
+		heights = append(heights, p.Height)
+		weights = append(weights, p.Weight)
+	}
+	result.Rows = append(result.Rows, typesRow, abilitiesRow)
+	// PokeAPI gives decimetres and hectograms
+	result.Rows = append(result.Rows, numericRow("height", heights, func(n int) string { return fmt.Sprintf("%.1f m", float64(n)/10) }))
+	result.Rows = append(result.Rows, numericRow("weight", weights, func(n int) string { return fmt.Sprintf("%.1f kg", float64(n)/10) }))
+
+	for i := range pokemons {
+		for j := range pokemons {
+			if i == j {
+				continue
+			}
+			best, multiplier := typechart.Best(types[i], types[j])
+			result.Matchups = append(result.Matchups, matchup{
+				Attacker:   result.Pokemon[i],
+				Defender:   result.Pokemon[j],
+				Type:       best,
+				Multiplier: multiplier,
+			})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/cmdline"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+// complete is the tab completion of the REPL. It gets the line up to the cursor
+// and returns where the current word starts, in runes, and the candidates for it.
+func (config *Config) complete(line string) (int, []string) {
+	start, candidates := config.candidates(line)
+	return utf8.RuneCountInString(line[:start]), candidates
+}
+
+// candidates returns where the current word starts, in bytes, and the candidates for it.
+func (config *Config) candidates(line string) (int, []string) {
+	// Split like the command will be, so quoted words are one word
+	words, word, start := cmdline.SplitPartial(line)
+	word = strings.ToLower(word)
+	if len(words) > 0 {
+		words[0] = strings.ToLower(words[0])
+	}
+
+	// First word: a command
+	if len(words) == 0 {
+		return start, withPrefix(config.commands.Names(), word)
+	}
+
+	command, ok := config.commands.Lookup(words[0])
+	if !ok {
+		return start, nil
+	}
+
+	switch command.Name {
+	case "help":
+		return start, withPrefix(config.commands.Names(), word)
+	case "explore":
+		areas := []string{}
+		for area := range config.knownAreas {
+			areas = append(areas, area)
+		}
+		return start, withPrefix(areas, word)
+	case "catch":
+		return start, withPrefix(config.lastExplored, word)
+	case "challenge":
+		if strings.HasPrefix(word, "-") {
+			flags := []string{}
+			for _, d := range battle.Difficulties {
+				flags = append(flags, "--difficulty="+d)
+			}
+			return start, withPrefix(flags, word)
+		}
+		areas := []string{}
+		for area := range config.knownAreas {
+			areas = append(areas, area)
+		}
+		return start, withPrefix(areas, word)
+	case "compare", "sprite":
+		names := append([]string{}, config.lastExplored...)
+		for _, pokemon := range config.save.Storage.All() {
+			names = append(names, strings.ToLower(pokemon.Name))
+		}
+		return start, withPrefix(names, word)
+	case "inspect", "deposit", "withdraw", "swap", "release", "nickname":
+		names := []string{}
+		for _, pokemon := range config.save.Storage.All() {
+			names = append(names, strings.ToLower(pokemon.DisplayName()))
+		}
+		return start, withPrefix(names, word)
+	case "battle":
+		if len(words) == 1 {
+			return start, withPrefix([]string{"host", "join"}, word)
+		}
+	case "gym":
+		return start, withPrefix([]string{"challenge"}, word)
+	case "stats":
+		return start, withPrefix([]string{"ball", "species", "area"}, word)
+	case "map":
+		return start, withPrefix([]string{"first", "last"}, word)
+	case "pokedex":
+		return start, withPrefix([]string{"caught", "seen", "completion"}, word)
+	case "trade":
+		if len(words) == 1 {
+			return start, withPrefix([]string{"offer", "accept", "decline", "log"}, word)
+		}
+		if words[1] == "offer" && len(words) == 2 {
+			names := []string{}
+			for _, pokemon := range config.save.Storage.Party {
+				names = append(names, strings.ToLower(pokemon.DisplayName()))
+			}
+			return start, withPrefix(names, word)
+		}
+		if words[1] == "offer" && len(words) == 3 {
+			trainers, _ := savefile.Trainers(config.dir)
+			return start, withPrefix(trainers, word)
+		}
+	case "trainer":
+		if len(words) == 1 {
+			return start, withPrefix([]string{"list", "new", "switch"}, word)
+		}
+		if words[1] == "switch" {
+			trainers, _ := savefile.Trainers(config.dir)
+			return start, withPrefix(trainers, word)
+		}
+	}
+
+	return start, nil
+}
+
+// withPrefix returns the sorted, unique words that start with prefix,
+// quoted if they need to be (see cmdline.Quote).
+func withPrefix(words []string, prefix string) []string {
+	seen := map[string]bool{}
+	matches := []string{}
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) && !seen[w] {
+			seen[w] = true
+			matches = append(matches, w)
+		}
+	}
+	sort.Strings(matches)
+	for i, m := range matches {
+		matches[i] = cmdline.Quote(m)
+	}
+	return matches
+}
@@ -1,3 +1,7 @@
 module github.com/neixir/pokedex
 
 go 1.24.4
+
+require golang.org/x/term v0.32.0
+
+require golang.org/x/sys v0.33.0 // indirect
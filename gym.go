@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/gym"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+// levelCap is the highest level the Pokemon of the trainer fight at, given their badges.
+func (config *Config) levelCap() int {
+	return gym.LevelCap(config.gyms, config.save.BadgeNames())
+}
+
+// currentGym is the gym of the location of the area explored last.
+func (config *Config) currentGym() (gym.Region, gym.Gym, error) {
+	if config.save.Location == "" {
+		return gym.Region{}, gym.Gym{}, fmt.Errorf("explore an area first, gyms are in cities")
+	}
+	areaInfo, err := pokeapi.GetLocationAreaInfo(config.save.Location, config.locationNamesCache)
+	if err != nil {
+		return gym.Region{}, gym.Gym{}, err
+	}
+
+	region, g, ok := gym.At(config.gyms, areaInfo.Location.Name)
+	if !ok {
+		return gym.Region{}, gym.Gym{}, fmt.Errorf("there's no gym in %s", areaInfo.Location.Name)
+	}
+	return region, g, nil
+}
+
+type gymResult struct {
+	Region   string       `json:"region"`
+	Location string       `json:"location"`
+	Leader   string       `json:"leader"`
+	Type     string       `json:"type"`
+	Badge    string       `json:"badge"`
+	Team     []gym.Member `json:"team"`
+	Earned   bool         `json:"earned"`
+	// The first badge of the region still missing, if it isn't this one
+	Needs string `json:"needs,omitempty"`
+}
+
+func (r gymResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "%s gym (%s)\n", r.Location, r.Region)
+	fmt.Fprintf(w, "Leader: %s, %s type\n", r.Leader, r.Type)
+	for _, m := range r.Team {
+		fmt.Fprintf(w, "  -%s (Lv. %d)\n", m.Name, m.Level)
+	}
+	switch {
+	case r.Earned:
+		fmt.Fprintf(w, "You already have the %s.\n", r.Badge)
+	case r.Needs != "":
+		fmt.Fprintf(w, "%s only battles trainers with the %s.\n", r.Leader, r.Needs)
+	default:
+		fmt.Fprintf(w, "Beat %s to earn the %s: gym challenge\n", r.Leader, r.Badge)
+	}
+}
+
+func (r gymResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, m := range r.Team {
+		rows = append(rows, []string{r.Leader, m.Name, fmt.Sprint(m.Level)})
+	}
+	return []string{"leader", "pokemon", "level"}, rows
+}
+
+type gymBattleResult struct {
+	battleResult
+	// The badge earned, if any
+	Badge    string `json:"badge,omitempty"`
+	LevelCap int    `json:"level_cap"`
+}
+
+func (r gymBattleResult) Text(w io.Writer) {
+	r.battleResult.Text(w)
+	if r.Badge != "" {
+		fmt.Fprintf(w, "You earned the %s! Your Pokemon now fight at up to level %d.\n", r.Badge, r.LevelCap)
+	}
+}
+
+func commandGym(config *Config) (any, error) {
+	region, g, err := config.currentGym()
+	if err != nil {
+		return nil, err
+	}
+	badges := config.save.BadgeNames()
+
+	result := gymResult{
+		Region:   region.Name,
+		Location: g.Location,
+		Leader:   g.Leader,
+		Type:     g.Type,
+		Badge:    g.Badge,
+		Team:     g.Team,
+		Earned:   slices.Contains(badges, g.Badge),
+	}
+	// Gyms are challenged in order
+	if next, ok := region.Next(badges); ok && !result.Earned && next.Badge != g.Badge {
+		result.Needs = next.Badge
+	}
+
+	switch strings.ToLower(config.args.Arg(0)) {
+	case "":
+		return result, nil
+	case "challenge":
+		if result.Needs != "" {
+			return nil, fmt.Errorf("%s only battles trainers with the %s", g.Leader, result.Needs)
+		}
+		return config.challengeGym(region, g, result.Earned)
+	}
+	return nil, fmt.Errorf("unknown gym action %q (use challenge)", config.args.Arg(0))
+}
+
+// challengeGym battles the leader, and gives the badge when the trainer wins for the first time.
+func (config *Config) challengeGym(region gym.Region, g gym.Gym, earned bool) (gymBattleResult, error) {
+	team, err := config.playerTeam()
+	if err != nil {
+		return gymBattleResult{}, err
+	}
+
+	leaderParty := []pc.Pokemon{}
+	for _, m := range g.Team {
+		pokemon, err := pokeapi.GetPokemon(m.Name)
+		if err != nil {
+			return gymBattleResult{}, err
+		}
+		p := pc.FromAPI(pokemon)
+		p.Level = m.Level
+		leaderParty = append(leaderParty, p)
+	}
+
+	rng := newBattleRand()
+	leader, err := battle.NewAI(battle.Hard, rng)
+	if err != nil {
+		return gymBattleResult{}, err
+	}
+	b, err := battle.New([2]string{config.trainer, g.Leader}, [2][]battle.Pokemon{team, battleTeam(leaderParty, battle.MaxLevel)}, rng)
+	if err != nil {
+		return gymBattleResult{}, err
+	}
+	err = battle.Run(b, [2]battle.Player{terminalPlayer{config}, leader})
+	if err != nil {
+		return gymBattleResult{}, err
+	}
+
+	// The stats can fail, the badge has to be saved anyway
+	result := gymBattleResult{battleResult: config.recordBattle(b.Result(g.Leader))}
+	if result.Won && !earned {
+		config.save.Badges = append(config.save.Badges, savefile.Badge{Name: g.Badge, Region: region.Name, Leader: g.Leader, At: time.Now()})
+		result.Badge = g.Badge
+	}
+	result.LevelCap = config.levelCap()
+	return result, config.persist()
+}
+
+type badgeLine struct {
+	Badge    string `json:"badge"`
+	Leader   string `json:"leader"`
+	Type     string `json:"type"`
+	Location string `json:"location"`
+	// When it was earned, nil if it wasn't
+	Earned *time.Time `json:"earned,omitempty"`
+}
+
+type badgeRegion struct {
+	Region   string      `json:"region"`
+	LevelCap int         `json:"level_cap"`
+	Badges   []badgeLine `json:"badges"`
+}
+
+type badgesResult struct {
+	LevelCap int           `json:"level_cap"`
+	Regions  []badgeRegion `json:"regions"`
+}
+
+func (r badgesResult) Text(w io.Writer) {
+	for _, region := range r.Regions {
+		earned := 0
+		for _, b := range region.Badges {
+			if b.Earned != nil {
+				earned++
+			}
+		}
+		fmt.Fprintf(w, "%s: %d/%d badges\n", region.Region, earned, len(region.Badges))
+		for _, b := range region.Badges {
+			mark := " "
+			if b.Earned != nil {
+				mark = "x"
+			}
+			fmt.Fprintf(w, "  [%s] %-14s %-13s %-9s %s\n", mark, b.Badge, b.Leader, b.Type, b.Location)
+		}
+	}
+	fmt.Fprintf(w, "Your Pokemon fight at up to level %d.\n", r.LevelCap)
+}
+
+func (r badgesResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, region := range r.Regions {
+		for _, b := range region.Badges {
+			earned := ""
+			if b.Earned != nil {
+				earned = b.Earned.Format(time.DateOnly)
+			}
+			rows = append(rows, []string{region.Region, b.Badge, b.Leader, b.Type, b.Location, earned})
+		}
+	}
+	return []string{"region", "badge", "leader", "type", "location", "earned"}, rows
+}
+
+func commandBadges(config *Config) (any, error) {
+	earned := map[string]time.Time{}
+	for _, b := range config.save.Badges {
+		earned[b.Name] = b.At
+	}
+
+	result := badgesResult{LevelCap: config.levelCap(), Regions: []badgeRegion{}}
+	for _, region := range config.gyms {
+		r := badgeRegion{Region: region.Name, LevelCap: region.Cap(config.save.BadgeNames()), Badges: []badgeLine{}}
+		for _, g := range region.Gyms {
+			line := badgeLine{Badge: g.Badge, Leader: g.Leader, Type: g.Type, Location: g.Location}
+			if at, ok := earned[g.Badge]; ok {
+				line.Earned = &at
+			}
+			r.Badges = append(r.Badges, line)
+		}
+		result.Regions = append(result.Regions, r)
+	}
+	return result, nil
+}
@@ -0,0 +1,211 @@
+package battle
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Difficulties of the AI, from the easiest
+const (
+	Easy   = "easy"
+	Normal = "normal"
+	Hard   = "hard"
+)
+
+var Difficulties = []string{Easy, Normal, Hard}
+
+// NewAI returns the player for an NPC trainer of the given difficulty:
+// random moves when easy, the one that hurts the most when normal, and
+// looking a few turns ahead when hard.
+func NewAI(difficulty string, rng *rand.Rand) (Player, error) {
+	switch difficulty {
+	case Easy:
+		return Random{rng}, nil
+	case Normal:
+		return Greedy{}, nil
+	case Hard:
+		return Minimax{Depth: 3}, nil
+	}
+	return nil, fmt.Errorf("unknown difficulty %q (easy, normal or hard)", difficulty)
+}
+
+// Random uses any of its moves.
+type Random struct {
+	rng *rand.Rand
+}
+
+func (p Random) Choose(v View) (Action, error) {
+	return Action{Kind: UseMove, Index: p.rng.Intn(len(v.Me().Moves))}, nil
+}
+
+func (p Random) Show(events []string) error {
+	return nil
+}
+
+// Greedy uses the move with the highest expected damage.
+type Greedy struct{}
+
+func (p Greedy) Choose(v View) (Action, error) {
+	me := v.Me()
+	return Action{Kind: UseMove, Index: bestMove(&me, &v.Foe)}, nil
+}
+
+func (p Greedy) Show(events []string) error {
+	return nil
+}
+
+func bestMove(attacker *Fighter, defender *Fighter) int {
+	best, bestDamage := 0, -1.0
+	for i, m := range attacker.Moves {
+		if d := ExpectedDamage(attacker, defender, m); d > bestDamage {
+			best, bestDamage = i, d
+		}
+	}
+	return best
+}
+
+// Minimax looks Depth turns ahead, with the expected damage of every move,
+// and chooses what is best assuming the opponent answers the best it can.
+// It only knows the Pokemon the opponent has out.
+type Minimax struct {
+	Depth int
+}
+
+func (p Minimax) Choose(v View) (Action, error) {
+	s := newSearchState(v)
+	best, bestScore := Action{Kind: UseMove}, 0.0
+	for i, a := range s.actions() {
+		score := s.worst(a, p.Depth)
+		if i == 0 || score > bestScore {
+			best, bestScore = a, score
+		}
+	}
+	return best, nil
+}
+
+func (p Minimax) Show(events []string) error {
+	return nil
+}
+
+// searchState is what Minimax knows of a battle: its team and the foe out.
+type searchState struct {
+	team   []Fighter
+	active int
+	foe    Fighter
+}
+
+func newSearchState(v View) searchState {
+	return searchState{team: append([]Fighter{}, v.Team...), active: v.Active, foe: v.Foe}
+}
+
+func (s searchState) clone() searchState {
+	s.team = append([]Fighter{}, s.team...)
+	return s
+}
+
+func (s searchState) over() bool {
+	return s.foe.Fainted() || s.left() == 0
+}
+
+func (s searchState) left() int {
+	n := 0
+	for _, f := range s.team {
+		if !f.Fainted() {
+			n++
+		}
+	}
+	return n
+}
+
+// actions are the moves of the Pokemon out and the switches it can do.
+func (s searchState) actions() []Action {
+	actions := []Action{}
+	for i := range s.team[s.active].Moves {
+		actions = append(actions, Action{Kind: UseMove, Index: i})
+	}
+	for i, f := range s.team {
+		if i != s.active && !f.Fainted() {
+			actions = append(actions, Action{Kind: Switch, Index: i})
+		}
+	}
+	return actions
+}
+
+// worst is the score of doing a, after the best answer of the foe.
+func (s searchState) worst(a Action, depth int) float64 {
+	worst := 0.0
+	for i := range s.foe.Moves {
+		next := s.play(a, i)
+		score := next.best(depth - 1)
+		if i == 0 || score < worst {
+			worst = score
+		}
+	}
+	return worst
+}
+
+// best is the score of the best action, looking depth turns ahead.
+func (s searchState) best(depth int) float64 {
+	if depth <= 0 || s.over() {
+		return s.score()
+	}
+	best := 0.0
+	for i, a := range s.actions() {
+		score := s.worst(a, depth)
+		if i == 0 || score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// play is the turn where the side does a and the foe uses its move, with the expected damage.
+func (s searchState) play(a Action, foeMove int) searchState {
+	s = s.clone()
+	if a.Kind == Switch {
+		s.active = a.Index
+	}
+
+	me := &s.team[s.active]
+	hit := func() {
+		if !me.Fainted() && !s.foe.Fainted() {
+			me.HP -= int(ExpectedDamage(&s.foe, me, s.foe.Moves[foeMove]))
+		}
+	}
+	attack := func() {
+		if a.Kind == UseMove && !me.Fainted() && !s.foe.Fainted() {
+			s.foe.HP -= int(ExpectedDamage(me, &s.foe, me.Moves[a.Index]))
+		}
+	}
+	// On a speed tie, expect the worst
+	if me.Stats["speed"] > s.foe.Stats["speed"] {
+		attack()
+		hit()
+	} else {
+		hit()
+		attack()
+	}
+
+	if me.Fainted() {
+		for i, f := range s.team {
+			if !f.Fainted() {
+				s.active = i
+				break
+			}
+		}
+	}
+	return s
+}
+
+// score is how good the state is: the health left in the team, minus the
+// health left of the foe, and a lot better once it faints.
+func (s searchState) score() float64 {
+	score := 0.0
+	for _, f := range s.team {
+		score += float64(max(f.HP, 0)) / float64(f.MaxHP)
+	}
+	if s.foe.Fainted() {
+		return score + 2
+	}
+	return score - float64(s.foe.HP)/float64(s.foe.MaxHP)
+}
@@ -0,0 +1,43 @@
+package battle
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAI(t *testing.T) {
+	if _, err := NewAI("impossible", nil); err == nil {
+		t.Errorf("expected an error for an unknown difficulty")
+	}
+
+	b, err := New([2]string{"brock", "misty"}, [2][]Pokemon{{geodude, pikachu}, {staryu}}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Earthquake is the move of geodude that hurts staryu the most
+	if a, _ := (Greedy{}).Choose(b.View(0)); a != (Action{Kind: UseMove, Index: 1}) {
+		t.Errorf("expected greedy geodude to use earthquake, got %+v", a)
+	}
+	// But staryu is faster and its surf knocks geodude out, better send pikachu
+	if a, _ := (Minimax{Depth: 2}).Choose(b.View(0)); a != (Action{Kind: Switch, Index: 1}) {
+		t.Errorf("expected minimax to switch to pikachu, got %+v", a)
+	}
+
+	// Over some battles, the hard AI beats the easy one
+	wins := 0
+	for seed := range int64(10) {
+		b, _ := New([2]string{"hard", "easy"}, [2][]Pokemon{{geodude, staryu, pikachu}, {pikachu, geodude, staryu}}, rand.New(rand.NewSource(seed)))
+		easy, _ := NewAI(Easy, rand.New(rand.NewSource(seed)))
+		hard, _ := NewAI(Hard, nil)
+		err := Run(b, [2]Player{hard, easy})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b.Winner() == 0 {
+			wins++
+		}
+	}
+	if wins < 7 {
+		t.Errorf("expected the hard AI to win most battles, won %d of 10", wins)
+	}
+}
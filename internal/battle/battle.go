@@ -0,0 +1,391 @@
+// Battles between two trainers, turn by turn, with the damage formula of the
+// games and the type chart. There are no abilities, items, status or accuracy:
+// every move hits.
+package battle
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/neixir/pokedex/internal/typechart"
+)
+
+const MaxLevel = 100
+
+// Pokemon is what a trainer brings to a battle.
+type Pokemon struct {
+	Name     string   `json:"name"`
+	Nickname string   `json:"nickname,omitempty"`
+	Level    int      `json:"level"`
+	Types    []string `json:"types"`
+	// Base stats by their PokeAPI name: hp, attack, defense, special-attack, special-defense and speed
+	BaseStats map[string]int `json:"base_stats"`
+}
+
+func (p Pokemon) DisplayName() string {
+	if p.Nickname != "" {
+		return p.Nickname
+	}
+	return p.Name
+}
+
+// Fighter is a Pokemon in a battle.
+type Fighter struct {
+	Pokemon
+	HP    int `json:"hp"`
+	MaxHP int `json:"max_hp"`
+	// Stats at its level, without hp (that's MaxHP)
+	Stats map[string]int `json:"stats"`
+	Moves []Move         `json:"moves"`
+}
+
+func NewFighter(p Pokemon) *Fighter {
+	p.Level = min(max(p.Level, 1), MaxLevel)
+	f := &Fighter{Pokemon: p, Stats: map[string]int{}, Moves: MovesFor(p.Types)}
+	for _, name := range []string{"attack", "defense", "special-attack", "special-defense", "speed"} {
+		f.Stats[name] = 2*p.BaseStats[name]*p.Level/100 + 5
+	}
+	// Stats without IVs, EVs or nature
+	f.MaxHP = 2*p.BaseStats["hp"]*p.Level/100 + p.Level + 10
+	f.HP = f.MaxHP
+	return f
+}
+
+func (f *Fighter) Fainted() bool {
+	return f.HP <= 0
+}
+
+// Damage is what move does to defender. roll is the random factor of the games, from 0.85 to 1.
+func Damage(attacker *Fighter, defender *Fighter, move Move, roll float64) int {
+	effectiveness := typechart.Effectiveness(move.Type, defender.Types...)
+	if effectiveness == 0 {
+		return 0
+	}
+	return max(1, int(baseDamage(attacker, defender, move)*effectiveness*roll))
+}
+
+// ExpectedDamage is the average Damage of move.
+func ExpectedDamage(attacker *Fighter, defender *Fighter, move Move) float64 {
+	return baseDamage(attacker, defender, move) * typechart.Effectiveness(move.Type, defender.Types...) * 0.925
+}
+
+// baseDamage is the formula of the games without the type chart and the random factor.
+func baseDamage(attacker *Fighter, defender *Fighter, move Move) float64 {
+	attack, defense := attacker.Stats["attack"], defender.Stats["defense"]
+	if move.Special {
+		attack, defense = attacker.Stats["special-attack"], defender.Stats["special-defense"]
+	}
+
+	damage := (float64(2*attacker.Level)/5+2)*float64(move.Power)*float64(attack)/float64(max(defense, 1))/50 + 2
+	for _, t := range attacker.Types {
+		if t == move.Type {
+			// Same type attack bonus
+			damage *= 1.5
+		}
+	}
+	return damage
+}
+
+type Side struct {
+	Trainer string     `json:"trainer"`
+	Team    []*Fighter `json:"team"`
+	Active  int        `json:"active"`
+}
+
+func (s *Side) active() *Fighter {
+	return s.Team[s.Active]
+}
+
+func (s *Side) name(f *Fighter) string {
+	return fmt.Sprintf("%s's %s", s.Trainer, f.DisplayName())
+}
+
+// Left is how many Pokemon of the side can still fight.
+func (s *Side) Left() int {
+	n := 0
+	for _, f := range s.Team {
+		if !f.Fainted() {
+			n++
+		}
+	}
+	return n
+}
+
+// Kinds of Action
+const (
+	UseMove = "move"
+	Switch  = "switch"
+	Forfeit = "forfeit"
+)
+
+// Action is what a trainer does in a turn. Index is the move of the active
+// Pokemon, or the Pokemon of the team to switch to.
+type Action struct {
+	Kind  string `json:"kind"`
+	Index int    `json:"index"`
+}
+
+type Battle struct {
+	Sides [2]*Side
+	Turn  int
+
+	winner int
+	rng    *rand.Rand
+	// Players still choosing, see Wait
+	choosing sync.WaitGroup
+}
+
+var ErrEmptyTeam = errors.New("a trainer needs at least one Pokemon to battle")
+
+// New starts a battle between two trainers. rng decides the random factor
+// of the damage and who moves first on a speed tie.
+func New(trainers [2]string, teams [2][]Pokemon, rng *rand.Rand) (*Battle, error) {
+	b := &Battle{winner: -1, rng: rng}
+	for i := range b.Sides {
+		if len(teams[i]) == 0 {
+			return nil, fmt.Errorf("%s: %w", trainers[i], ErrEmptyTeam)
+		}
+		side := &Side{Trainer: trainers[i]}
+		for _, p := range teams[i] {
+			side.Team = append(side.Team, NewFighter(p))
+		}
+		b.Sides[i] = side
+	}
+	return b, nil
+}
+
+// Start returns what happens before the first turn.
+func (b *Battle) Start() []string {
+	return []string{
+		fmt.Sprintf("%s wants to battle %s!", b.Sides[1].Trainer, b.Sides[0].Trainer),
+		fmt.Sprintf("%s sent out %s!", b.Sides[0].Trainer, b.Sides[0].active().DisplayName()),
+		fmt.Sprintf("%s sent out %s!", b.Sides[1].Trainer, b.Sides[1].active().DisplayName()),
+	}
+}
+
+func (b *Battle) Over() bool {
+	return b.winner >= 0
+}
+
+// Winner is the side that won, -1 while the battle goes on.
+func (b *Battle) Winner() int {
+	return b.winner
+}
+
+// Validate tells whether side can do a.
+func (b *Battle) Validate(side int, a Action) error {
+	s := b.Sides[side]
+	switch a.Kind {
+	case UseMove:
+		if a.Index < 0 || a.Index >= len(s.active().Moves) {
+			return fmt.Errorf("%s has no move %d", s.active().DisplayName(), a.Index+1)
+		}
+	case Switch:
+		if a.Index < 0 || a.Index >= len(s.Team) {
+			return fmt.Errorf("there is no Pokemon %d in your team", a.Index+1)
+		}
+		if a.Index == s.Active {
+			return fmt.Errorf("%s is already out", s.Team[a.Index].DisplayName())
+		}
+		if s.Team[a.Index].Fainted() {
+			return fmt.Errorf("%s has fainted", s.Team[a.Index].DisplayName())
+		}
+	case Forfeit:
+	default:
+		return fmt.Errorf("unknown action %q", a.Kind)
+	}
+	return nil
+}
+
+// Play runs a turn with a valid action of each side and returns what happened.
+// Switches go first, then the moves, the fastest Pokemon first.
+func (b *Battle) Play(actions [2]Action) []string {
+	b.Turn++
+	events := []string{}
+
+	for i, a := range actions {
+		if a.Kind == Forfeit {
+			b.winner = 1 - i
+			return append(events, fmt.Sprintf("%s forfeited. %s wins!", b.Sides[i].Trainer, b.Sides[1-i].Trainer))
+		}
+	}
+
+	for i, a := range actions {
+		if a.Kind == Switch {
+			s := b.Sides[i]
+			events = append(events, fmt.Sprintf("%s withdrew %s and sent out %s!", s.Trainer, s.active().DisplayName(), s.Team[a.Index].DisplayName()))
+			s.Active = a.Index
+		}
+	}
+
+	first := 0
+	speed0, speed1 := b.Sides[0].active().Stats["speed"], b.Sides[1].active().Stats["speed"]
+	if speed1 > speed0 || (speed1 == speed0 && b.rng.Intn(2) == 1) {
+		first = 1
+	}
+	for _, i := range []int{first, 1 - first} {
+		if actions[i].Kind == UseMove {
+			events = append(events, b.attack(i, actions[i].Index)...)
+		}
+	}
+
+	for i, s := range b.Sides {
+		if !s.active().Fainted() {
+			continue
+		}
+		if s.Left() == 0 {
+			b.winner = 1 - i
+			return append(events, fmt.Sprintf("%s is out of usable Pokemon! %s wins!", s.Trainer, b.Sides[1-i].Trainer))
+		}
+		// The next one that can fight comes out
+		for j, f := range s.Team {
+			if !f.Fainted() {
+				s.Active = j
+				events = append(events, fmt.Sprintf("%s sent out %s!", s.Trainer, f.DisplayName()))
+				break
+			}
+		}
+	}
+	return events
+}
+
+func (b *Battle) attack(side int, move int) []string {
+	attackerSide, defenderSide := b.Sides[side], b.Sides[1-side]
+	attacker, defender := attackerSide.active(), defenderSide.active()
+	if attacker.Fainted() || defender.Fainted() {
+		return nil
+	}
+
+	m := attacker.Moves[move]
+	events := []string{fmt.Sprintf("%s used %s!", attackerSide.name(attacker), m.Name)}
+	switch effectiveness := typechart.Effectiveness(m.Type, defender.Types...); {
+	case effectiveness == 0:
+		return append(events, fmt.Sprintf("It doesn't affect %s...", defenderSide.name(defender)))
+	case effectiveness > 1:
+		events = append(events, "It's super effective!")
+	case effectiveness < 1:
+		events = append(events, "It's not very effective...")
+	}
+
+	damage := min(Damage(attacker, defender, m, 0.85+0.15*b.rng.Float64()), defender.HP)
+	defender.HP -= damage
+	events = append(events, fmt.Sprintf("%s lost %d HP (%d/%d).", defenderSide.name(defender), damage, defender.HP, defender.MaxHP))
+	if defender.Fainted() {
+		events = append(events, fmt.Sprintf("%s fainted!", defenderSide.name(defender)))
+	}
+	return events
+}
+
+// View is what a side knows of the battle: all about its team and the
+// Pokemon the opponent has out.
+type View struct {
+	Turn     int       `json:"turn"`
+	Trainer  string    `json:"trainer"`
+	Team     []Fighter `json:"team"`
+	Active   int       `json:"active"`
+	Opponent string    `json:"opponent"`
+	Foe      Fighter   `json:"foe"`
+	// How many Pokemon of the opponent can still fight
+	FoeLeft int `json:"foe_left"`
+}
+
+// Me is the Pokemon the side has out.
+func (v View) Me() Fighter {
+	return v.Team[v.Active]
+}
+
+func (b *Battle) View(side int) View {
+	s, foe := b.Sides[side], b.Sides[1-side]
+	v := View{
+		Turn:     b.Turn + 1,
+		Trainer:  s.Trainer,
+		Team:     []Fighter{},
+		Active:   s.Active,
+		Opponent: foe.Trainer,
+		Foe:      *foe.active(),
+		FoeLeft:  foe.Left(),
+	}
+	for _, f := range s.Team {
+		v.Team = append(v.Team, *f)
+	}
+	return v
+}
+
+// Player chooses the actions of one side and is shown what happens.
+type Player interface {
+	Choose(v View) (Action, error)
+	Show(events []string) error
+}
+
+// Run plays a battle to the end. Both players choose at the same time, and
+// are asked again until their choice is valid.
+//
+// If a player fails, Run returns its error without waiting for the other one,
+// which may still be choosing: Wait waits for it.
+func Run(b *Battle, players [2]Player) error {
+	err := showAll(players, b.Start())
+	if err != nil {
+		return err
+	}
+
+	for !b.Over() {
+		var actions [2]Action
+		errs := make(chan error, 2)
+		stop := make(chan struct{})
+		for i, p := range players {
+			b.choosing.Add(1)
+			go func() {
+				defer b.choosing.Done()
+				for {
+					a, err := p.Choose(b.View(i))
+					if err != nil {
+						errs <- err
+						return
+					}
+					err = b.Validate(i, a)
+					if err == nil {
+						actions[i] = a
+						errs <- nil
+						return
+					}
+					select {
+					case <-stop:
+						// Nobody is waiting for the choice anymore
+						errs <- nil
+						return
+					default:
+					}
+					err = p.Show([]string{err.Error()})
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		for range players {
+			err := <-errs
+			if err != nil {
+				close(stop)
+				return err
+			}
+		}
+
+		err = showAll(players, b.Play(actions))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait waits for the players Run stopped waiting for when the other one failed.
+func (b *Battle) Wait() {
+	b.choosing.Wait()
+}
+
+func showAll(players [2]Player, events []string) error {
+	return errors.Join(players[0].Show(events), players[1].Show(events))
+}
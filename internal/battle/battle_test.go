@@ -0,0 +1,306 @@
+package battle
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	pikachu = Pokemon{Name: "pikachu", Level: 25, Types: []string{"electric"},
+		BaseStats: map[string]int{"hp": 35, "attack": 55, "defense": 40, "special-attack": 50, "special-defense": 50, "speed": 90}}
+	geodude = Pokemon{Name: "geodude", Level: 25, Types: []string{"rock", "ground"},
+		BaseStats: map[string]int{"hp": 40, "attack": 80, "defense": 100, "special-attack": 30, "special-defense": 30, "speed": 20}}
+	staryu = Pokemon{Name: "staryu", Level: 25, Types: []string{"water"},
+		BaseStats: map[string]int{"hp": 30, "attack": 45, "defense": 55, "special-attack": 70, "special-defense": 55, "speed": 85}}
+)
+
+func TestMovesFor(t *testing.T) {
+	moves := MovesFor([]string{"rock", "ground"})
+	names := []string{}
+	for _, m := range moves {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "rock-slide,earthquake,tackle" {
+		t.Errorf("unexpected moves %v", names)
+	}
+}
+
+func TestDamage(t *testing.T) {
+	p, g, s := NewFighter(pikachu), NewFighter(geodude), NewFighter(staryu)
+	thunderbolt := p.Moves[0]
+
+	if d := Damage(p, g, thunderbolt, 1); d != 0 {
+		t.Errorf("expected thunderbolt not to affect geodude, got %d", d)
+	}
+	// Super effective, and same type
+	if super, tackle := Damage(p, s, thunderbolt, 1), Damage(p, s, p.Moves[1], 1); super <= 2*tackle {
+		t.Errorf("expected thunderbolt (%d) to do much more than tackle (%d) to staryu", super, tackle)
+	}
+	if low, high := Damage(p, s, thunderbolt, 0.85), Damage(p, s, thunderbolt, 1); low >= high {
+		t.Errorf("expected the roll to matter, got %d and %d", low, high)
+	}
+}
+
+// scripted always uses its first move, or forfeits after some turns.
+type scripted struct {
+	forfeitAt int
+	events    []string
+}
+
+func (p *scripted) Choose(v View) (Action, error) {
+	if p.forfeitAt > 0 && v.Turn >= p.forfeitAt {
+		return Action{Kind: Forfeit}, nil
+	}
+	return Action{Kind: UseMove, Index: 0}, nil
+}
+
+func (p *scripted) Show(events []string) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	b, err := New([2]string{"ash", "misty"}, [2][]Pokemon{{pikachu}, {geodude, staryu}}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ash, misty := &scripted{}, &scripted{}
+
+	err = Run(b, [2]Player{ash, misty})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Winner() != 1 {
+		t.Errorf("expected geodude to beat pikachu, got winner %d", b.Winner())
+	}
+	if r := b.Result("misty"); r.Won || r.Winner != "misty" || r.Opponent != "misty" || r.Turns != b.Turn {
+		t.Errorf("expected ash to have lost against misty, got %+v", r)
+	}
+	all := strings.Join(ash.events, "\n")
+	if !strings.Contains(all, "It doesn't affect misty's geodude...") || !strings.Contains(all, "misty wins!") {
+		t.Errorf("unexpected events:\n%s", all)
+	}
+
+	if err := b.Validate(0, Action{Kind: Switch, Index: 0}); err == nil {
+		t.Errorf("expected an error switching to the Pokemon that is out")
+	}
+	if _, err := New([2]string{"ash", "misty"}, [2][]Pokemon{{pikachu}, {}}, nil); !errors.Is(err, ErrEmptyTeam) {
+		t.Errorf("expected ErrEmptyTeam, got %v", err)
+	}
+}
+
+func TestNetworkBattle(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type hosted struct {
+		result Result
+		err    error
+	}
+	done := make(chan hosted)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- hosted{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := Host(conn, "ash", []Pokemon{pikachu}, &scripted{}, rand.New(rand.NewSource(1)))
+		done <- hosted{result, err}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	misty := &scripted{forfeitAt: 1}
+	joined, err := Join(conn, "misty", []Pokemon{staryu}, misty)
+	if err != nil {
+		t.Fatalf("unexpected error joining: %v", err)
+	}
+	host := <-done
+	if host.err != nil {
+		t.Fatalf("unexpected error hosting: %v", host.err)
+	}
+
+	if !host.result.Won || joined.Won || joined.Winner != "ash" || host.result.Opponent != "misty" || joined.Opponent != "ash" {
+		t.Errorf("expected ash to win, got %+v and %+v", host.result, joined)
+	}
+	if !strings.Contains(strings.Join(misty.events, "\n"), "misty forfeited") {
+		t.Errorf("expected the client to be shown the events, got %v", misty.events)
+	}
+}
+
+func TestNetworkVersion(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := Host(server, "ash", []Pokemon{pikachu}, &scripted{}, rand.New(rand.NewSource(1)))
+		server.Close()
+		done <- err
+	}()
+
+	json.NewEncoder(client).Encode(Message{V: Version + 1, Type: msgHello, Trainer: "misty", Team: []Pokemon{staryu}})
+	line, _ := bufio.NewReader(client).ReadString('\n')
+	if !strings.Contains(line, `"type":"error"`) {
+		t.Errorf("expected the host to answer with an error, got %q", line)
+	}
+	if err := <-done; !errors.Is(err, ErrVersion) {
+		t.Errorf("expected ErrVersion, got %v", err)
+	}
+}
+
+func TestNetworkForgedTeam(t *testing.T) {
+	strong := staryu
+	strong.BaseStats = map[string]int{"hp": 9999, "attack": 9999, "defense": 9999, "special-attack": 9999, "special-defense": 9999, "speed": 9999}
+	cosmic := staryu
+	cosmic.Types = []string{"cosmic"}
+	tests := map[string]Message{
+		"too many":       {Trainer: "misty", Team: []Pokemon{staryu, staryu, staryu, staryu, staryu, staryu, staryu}},
+		"base stats":     {Trainer: "misty", Team: []Pokemon{strong}},
+		"unknown type":   {Trainer: "misty", Team: []Pokemon{cosmic}},
+		"trainer name":   {Trainer: "misty\x1b[2J", Team: []Pokemon{staryu}},
+		"missing stats":  {Trainer: "misty", Team: []Pokemon{{Name: "staryu", Level: 25, Types: []string{"water"}}}},
+		"too many types": {Trainer: "misty", Team: []Pokemon{{Name: "staryu", Level: 25, Types: []string{"water", "psychic", "fire"}, BaseStats: staryu.BaseStats}}},
+	}
+
+	for name, hello := range tests {
+		client, server := net.Pipe()
+		done := make(chan error)
+		go func() {
+			_, err := Host(server, "ash", []Pokemon{pikachu}, &scripted{}, rand.New(rand.NewSource(1)))
+			server.Close()
+			done <- err
+		}()
+
+		hello.V, hello.Type = Version, msgHello
+		json.NewEncoder(client).Encode(hello)
+		line, _ := bufio.NewReader(client).ReadString('\n')
+		if !strings.Contains(line, `"type":"error"`) {
+			t.Errorf("%s: expected the host to answer with an error, got %q", name, line)
+		}
+		if err := <-done; err == nil {
+			t.Errorf("%s: expected the host to refuse the team", name)
+		}
+		client.Close()
+	}
+}
+
+func TestNetworkForgedHost(t *testing.T) {
+	view := View{Trainer: "misty", Opponent: "ash", Team: []Fighter{*NewFighter(staryu)}, Foe: *NewFighter(pikachu)}
+	badFoe := view
+	badFoe.Foe.Nickname = "\x1b]0;pwned\x07"
+	badActive := view
+	badActive.Active = 3
+	tests := map[string][]Message{
+		"trainer name": {{Type: msgHello, Trainer: "ash\x1b[2J", Team: []Pokemon{pikachu}}},
+		"event":        {{Type: msgHello, Trainer: "ash", Team: []Pokemon{pikachu}}, {Type: msgEvents, Events: []string{"ash sent out pikachu!\x1b[2J"}}},
+		"foe name":     {{Type: msgHello, Trainer: "ash", Team: []Pokemon{pikachu}}, {Type: msgRequest, View: &badFoe}},
+		"active":       {{Type: msgHello, Trainer: "ash", Team: []Pokemon{pikachu}}, {Type: msgRequest, View: &badActive}},
+		"winner":       {{Type: msgHello, Trainer: "ash", Team: []Pokemon{pikachu}}, {Type: msgEnd, Winner: "gary"}},
+	}
+
+	for name, messages := range tests {
+		client, server := net.Pipe()
+		done := make(chan error)
+		misty := &scripted{}
+		go func() {
+			_, err := Join(client, "misty", []Pokemon{staryu}, misty)
+			client.Close()
+			done <- err
+		}()
+
+		r := bufio.NewReader(server)
+		r.ReadString('\n')
+		for _, m := range messages {
+			m.V = Version
+			json.NewEncoder(server).Encode(m)
+		}
+		line, _ := r.ReadString('\n')
+		if !strings.Contains(line, `"type":"error"`) {
+			t.Errorf("%s: expected the client to answer with an error, got %q", name, line)
+		}
+		if err := <-done; err == nil {
+			t.Errorf("%s: expected the client to refuse the message", name)
+		}
+		if len(misty.events) != 0 {
+			t.Errorf("%s: expected nothing shown, got %q", name, misty.events)
+		}
+		server.Close()
+	}
+}
+
+// stuck chooses only when told to.
+type stuck struct {
+	scripted
+	choose chan struct{}
+}
+
+func (p *stuck) Choose(v View) (Action, error) {
+	<-p.choose
+	return p.scripted.Choose(v)
+}
+
+// failing can't choose.
+type failing struct {
+	scripted
+}
+
+func (p *failing) Choose(v View) (Action, error) {
+	return Action{}, errors.New("the trainer ran away")
+}
+
+func TestRunReturnsOnFirstError(t *testing.T) {
+	b, err := New([2]string{"ash", "misty"}, [2][]Pokemon{{pikachu}, {staryu}}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	misty := &stuck{choose: make(chan struct{})}
+
+	err = Run(b, [2]Player{&failing{}, misty})
+	if err == nil || err.Error() != "the trainer ran away" {
+		t.Fatalf("expected the error of ash, got %v", err)
+	}
+
+	close(misty.choose)
+	b.Wait()
+}
+
+func TestNetworkTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+	Timeout = 50 * time.Millisecond
+
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan error)
+	go func() {
+		_, err := Host(server, "ash", []Pokemon{pikachu}, &scripted{}, rand.New(rand.NewSource(1)))
+		done <- err
+	}()
+
+	// misty joins, and then never chooses
+	json.NewEncoder(client).Encode(Message{V: Version, Type: msgHello, Trainer: "misty", Team: []Pokemon{staryu}})
+	go io.Copy(io.Discard, client)
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "took too long") {
+			t.Errorf("expected the host to give up waiting, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the host is still waiting")
+	}
+}
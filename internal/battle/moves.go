@@ -0,0 +1,46 @@
+package battle
+
+// Move is an attack. The Pokedex doesn't know the moves of each Pokemon,
+// so every Pokemon gets tackle and a typical move of each of its types.
+type Move struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Power int    `json:"power"`
+	// Special moves use special-attack and special-defense instead of attack and defense
+	Special bool `json:"special"`
+}
+
+var tackle = Move{Name: "tackle", Type: "normal", Power: 40}
+
+// typeMoves is the move a Pokemon gets for each of its types.
+var typeMoves = map[string]Move{
+	"normal":   {Name: "body-slam", Type: "normal", Power: 85},
+	"fighting": {Name: "brick-break", Type: "fighting", Power: 75},
+	"flying":   {Name: "wing-attack", Type: "flying", Power: 60},
+	"poison":   {Name: "sludge-bomb", Type: "poison", Power: 90, Special: true},
+	"ground":   {Name: "earthquake", Type: "ground", Power: 100},
+	"rock":     {Name: "rock-slide", Type: "rock", Power: 75},
+	"bug":      {Name: "x-scissor", Type: "bug", Power: 80},
+	"ghost":    {Name: "shadow-ball", Type: "ghost", Power: 80, Special: true},
+	"steel":    {Name: "iron-head", Type: "steel", Power: 80},
+	"fire":     {Name: "flamethrower", Type: "fire", Power: 90, Special: true},
+	"water":    {Name: "surf", Type: "water", Power: 90, Special: true},
+	"grass":    {Name: "energy-ball", Type: "grass", Power: 90, Special: true},
+	"electric": {Name: "thunderbolt", Type: "electric", Power: 90, Special: true},
+	"psychic":  {Name: "psychic", Type: "psychic", Power: 90, Special: true},
+	"ice":      {Name: "ice-beam", Type: "ice", Power: 90, Special: true},
+	"dragon":   {Name: "dragon-claw", Type: "dragon", Power: 80},
+	"dark":     {Name: "crunch", Type: "dark", Power: 80},
+	"fairy":    {Name: "dazzling-gleam", Type: "fairy", Power: 80, Special: true},
+}
+
+// MovesFor returns the moves of a Pokemon with the given types: one for each type and tackle.
+func MovesFor(types []string) []Move {
+	moves := []Move{}
+	for _, t := range types {
+		if move, ok := typeMoves[t]; ok {
+			moves = append(moves, move)
+		}
+	}
+	return append(moves, tackle)
+}
@@ -0,0 +1,356 @@
+package battle
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"time"
+	"unicode"
+
+	"github.com/neixir/pokedex/internal/typechart"
+)
+
+// Battles over the network: the host runs the battle and the client that
+// joins it only chooses and is shown what happens. They talk with one JSON
+// message per line:
+//
+//	client -> host   {"v":1,"type":"hello","trainer":"misty","team":[...]}
+//	host -> client   {"v":1,"type":"hello","trainer":"ash","team":[...]}
+//	host -> client   {"v":1,"type":"events","events":["ash sent out pikachu!", ...]}
+//	host -> client   {"v":1,"type":"request","view":{...}}
+//	client -> host   {"v":1,"type":"choice","action":{"kind":"move","index":0}}
+//	...
+//	host -> client   {"v":1,"type":"end","winner":"ash"}
+//
+// Either side can send {"type":"error"} and hang up.
+
+// Version of the protocol. Host and client must speak the same one.
+const Version = 1
+
+// Types of Message
+const (
+	msgHello   = "hello"
+	msgRequest = "request"
+	msgChoice  = "choice"
+	msgEvents  = "events"
+	msgEnd     = "end"
+	msgError   = "error"
+)
+
+type Message struct {
+	V       int       `json:"v"`
+	Type    string    `json:"type"`
+	Trainer string    `json:"trainer,omitempty"`
+	Team    []Pokemon `json:"team,omitempty"`
+	View    *View     `json:"view,omitempty"`
+	Action  *Action   `json:"action,omitempty"`
+	Events  []string  `json:"events,omitempty"`
+	Winner  string    `json:"winner,omitempty"`
+	// In "end", whether who gets it won
+	Won   bool   `json:"won,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+var ErrVersion = errors.New("the other Pokedex speaks another version of the battle protocol")
+
+// MaxTeam is the most Pokemon a trainer can bring, a full party.
+const MaxTeam = 6
+
+// validTeam checks a team sent by the other Pokedex, which could send anything.
+func validTeam(team []Pokemon) error {
+	if len(team) > MaxTeam {
+		return fmt.Errorf("a team has up to %d Pokemon, got %d", MaxTeam, len(team))
+	}
+	for _, p := range team {
+		if !validName(p.Name) || (p.Nickname != "" && !validName(p.Nickname)) {
+			return fmt.Errorf("invalid Pokemon name %q", p.DisplayName())
+		}
+		if len(p.Types) == 0 || len(p.Types) > 2 {
+			return fmt.Errorf("%s has %d types, Pokemon have one or two", p.Name, len(p.Types))
+		}
+		for _, t := range p.Types {
+			if !typechart.Known(t) {
+				return fmt.Errorf("%s has an unknown type %q", p.Name, t)
+			}
+		}
+		for _, stat := range []string{"hp", "attack", "defense", "special-attack", "special-defense", "speed"} {
+			if n := p.BaseStats[stat]; n < 1 || n > 255 {
+				return fmt.Errorf("%s has a base %s of %d, it goes from 1 to 255", p.Name, stat, n)
+			}
+		}
+	}
+	return nil
+}
+
+// validName tells whether name can be shown to the trainer: short, and without control characters.
+func validName(name string) bool {
+	return validText(name, 64)
+}
+
+// validText tells whether text can be shown: at most max bytes, none of them
+// control characters that would mess with the terminal.
+func validText(text string, max int) bool {
+	if text == "" || len(text) > max {
+		return false
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// maxEvent is the longest line of what happens in a battle the host can send.
+const maxEvent = 256
+
+// validView checks a view sent by the host, before its trainer gets to see it.
+func validView(v View) error {
+	if !validName(v.Trainer) || !validName(v.Opponent) {
+		return fmt.Errorf("invalid trainer names %q and %q", v.Trainer, v.Opponent)
+	}
+	if len(v.Team) == 0 || len(v.Team) > MaxTeam || v.Active < 0 || v.Active >= len(v.Team) {
+		return fmt.Errorf("invalid team of %d Pokemon with %d out", len(v.Team), v.Active)
+	}
+	for _, f := range append(slices.Clone(v.Team), v.Foe) {
+		if !validName(f.Name) || (f.Nickname != "" && !validName(f.Nickname)) {
+			return fmt.Errorf("invalid Pokemon name %q", f.DisplayName())
+		}
+		for _, m := range f.Moves {
+			if !validName(m.Name) {
+				return fmt.Errorf("invalid move name %q", m.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Result is how a battle ended for one of the trainers.
+type Result struct {
+	Opponent string `json:"opponent"`
+	Winner   string `json:"winner"`
+	Won      bool   `json:"won"`
+	Turns    int    `json:"turns"`
+}
+
+// Result is how the battle ended for the first side, once it's over.
+func (b *Battle) Result(opponent string) Result {
+	return Result{Opponent: opponent, Winner: b.Sides[b.Winner()].Trainer, Won: b.Winner() == 0, Turns: b.Turn}
+}
+
+// Timeout is how long to wait for each message of the other Pokedex, e.g.
+// while its trainer chooses, when the connection supports deadlines.
+var Timeout = 5 * time.Minute
+
+type conn struct {
+	r *bufio.Reader
+	w io.Writer
+	// Set if the connection supports deadlines, like net.Conn
+	deadlines interface{ SetDeadline(time.Time) error }
+}
+
+func newConn(rw io.ReadWriter) *conn {
+	c := &conn{r: bufio.NewReader(rw), w: rw}
+	c.deadlines, _ = rw.(interface{ SetDeadline(time.Time) error })
+	return c
+}
+
+// wait gives the next read or write Timeout to happen.
+func (c *conn) wait() {
+	if c.deadlines != nil {
+		c.deadlines.SetDeadline(time.Now().Add(Timeout))
+	}
+}
+
+func (c *conn) send(m Message) error {
+	m.V = Version
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.wait()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+func (c *conn) receive() (Message, error) {
+	c.wait()
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Message{}, fmt.Errorf("the other trainer left the battle")
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return Message{}, fmt.Errorf("the other trainer took too long, over %v", Timeout)
+		}
+		return Message{}, err
+	}
+
+	m := Message{}
+	err = json.Unmarshal(line, &m)
+	if err != nil {
+		return Message{}, fmt.Errorf("invalid message: %w", err)
+	}
+	if m.V != Version {
+		return Message{}, fmt.Errorf("%w (%d, we speak %d)", ErrVersion, m.V, Version)
+	}
+	if m.Type == msgError {
+		return Message{}, fmt.Errorf("the other Pokedex said: %s", m.Error)
+	}
+	return m, nil
+}
+
+// expect receives a message of the given type.
+func (c *conn) expect(messageType string) (Message, error) {
+	m, err := c.receive()
+	if err != nil {
+		return m, err
+	}
+	if m.Type != messageType {
+		return m, fmt.Errorf("expected a %s message, got %q", messageType, m.Type)
+	}
+	return m, nil
+}
+
+// fail tells the other side what went wrong, and returns err.
+func (c *conn) fail(err error) error {
+	c.send(Message{Type: msgError, Error: err.Error()})
+	return err
+}
+
+// remote is the player at the other end of the connection.
+type remote struct {
+	c *conn
+}
+
+func (p remote) Choose(v View) (Action, error) {
+	err := p.c.send(Message{Type: msgRequest, View: &v})
+	if err != nil {
+		return Action{}, err
+	}
+	m, err := p.c.expect(msgChoice)
+	if err != nil {
+		return Action{}, err
+	}
+	if m.Action == nil {
+		return Action{}, fmt.Errorf("a choice without an action")
+	}
+	return *m.Action, nil
+}
+
+func (p remote) Show(events []string) error {
+	return p.c.send(Message{Type: msgEvents, Events: events})
+}
+
+// Host runs a battle against the client at the other end of rw. If the battle
+// fails, rw is closed when it's an io.Closer, and Host waits for local to
+// finish choosing, if it was, before returning.
+func Host(rw io.ReadWriter, trainer string, team []Pokemon, local Player, rng *rand.Rand) (Result, error) {
+	c := newConn(rw)
+	hello, err := c.expect(msgHello)
+	if err != nil {
+		return Result{}, c.fail(err)
+	}
+	if !validName(hello.Trainer) {
+		return Result{}, c.fail(fmt.Errorf("invalid trainer name %q", hello.Trainer))
+	}
+	err = validTeam(hello.Team)
+	if err != nil {
+		return Result{}, c.fail(err)
+	}
+
+	b, err := New([2]string{trainer, hello.Trainer}, [2][]Pokemon{team, hello.Team}, rng)
+	if err != nil {
+		return Result{}, c.fail(err)
+	}
+	err = c.send(Message{Type: msgHello, Trainer: trainer, Team: team})
+	if err != nil {
+		return Result{}, err
+	}
+
+	err = Run(b, [2]Player{local, remote{c}})
+	if err != nil {
+		c.fail(err)
+		// Unblocks the remote player, if it's the one still choosing
+		if closer, ok := rw.(io.Closer); ok {
+			closer.Close()
+		}
+		local.Show([]string{fmt.Sprintf("The battle is over: %v", err)})
+		b.Wait()
+		return Result{}, err
+	}
+
+	result := b.Result(hello.Trainer)
+	return result, c.send(Message{Type: msgEnd, Winner: result.Winner, Won: !result.Won})
+}
+
+// Join plays the battle of the host at the other end of rw.
+func Join(rw io.ReadWriter, trainer string, team []Pokemon, local Player) (Result, error) {
+	if len(team) == 0 {
+		return Result{}, ErrEmptyTeam
+	}
+
+	c := newConn(rw)
+	err := c.send(Message{Type: msgHello, Trainer: trainer, Team: team})
+	if err != nil {
+		return Result{}, err
+	}
+	hello, err := c.expect(msgHello)
+	if err != nil {
+		return Result{}, err
+	}
+	// Everything the host sends ends up in our terminal
+	if !validName(hello.Trainer) {
+		return Result{}, c.fail(fmt.Errorf("invalid trainer name %q", hello.Trainer))
+	}
+
+	turns := 0
+	for {
+		m, err := c.receive()
+		if err != nil {
+			return Result{}, err
+		}
+
+		switch m.Type {
+		case msgEvents:
+			for _, event := range m.Events {
+				if !validText(event, maxEvent) {
+					return Result{}, c.fail(fmt.Errorf("invalid event %q", event))
+				}
+			}
+			err = local.Show(m.Events)
+			if err != nil {
+				return Result{}, c.fail(err)
+			}
+		case msgRequest:
+			if m.View == nil {
+				return Result{}, c.fail(fmt.Errorf("a request without a view"))
+			}
+			err = validView(*m.View)
+			if err != nil {
+				return Result{}, c.fail(err)
+			}
+			turns = m.View.Turn
+			a, err := local.Choose(*m.View)
+			if err != nil {
+				return Result{}, c.fail(err)
+			}
+			err = c.send(Message{Type: msgChoice, Action: &a})
+			if err != nil {
+				return Result{}, err
+			}
+		case msgEnd:
+			if m.Winner != trainer && m.Winner != hello.Trainer {
+				return Result{}, c.fail(fmt.Errorf("invalid winner %q", m.Winner))
+			}
+			return Result{Opponent: hello.Trainer, Winner: m.Winner, Won: m.Won, Turns: turns}, nil
+		default:
+			return Result{}, c.fail(fmt.Errorf("unexpected %q message", m.Type))
+		}
+	}
+}
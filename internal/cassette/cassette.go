@@ -0,0 +1,215 @@
+// Cassette-style recording and replay of HTTP responses.
+// In record mode every response from the real server is saved to a fixture file;
+// in replay mode those files are served without touching the network, so the
+// Pokedex (and its tests) can run offline and deterministically.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+type Mode string
+
+const (
+	// Replay only serves recorded responses. A missing recording is an error.
+	Replay Mode = "replay"
+	// Record always goes to the network and (re)writes the recordings.
+	Record Mode = "record"
+	// Auto replays what is recorded and records what isn't.
+	Auto Mode = "auto"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(s)); m {
+	case Replay, Record, Auto:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown cassette mode %q (use replay, record or auto)", s)
+}
+
+// Episode is one recorded response, stored as a JSON file.
+type Episode struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// The body is kept as JSON when it is JSON (readable fixtures), as text
+	// when it is text and in base64 otherwise (sprites).
+	Body       json.RawMessage `json:"body,omitempty"`
+	BodyText   string          `json:"body_text,omitempty"`
+	BodyBase64 []byte          `json:"body_base64,omitempty"`
+}
+
+func (e *Episode) setBody(body []byte) {
+	switch {
+	case len(body) > 0 && json.Valid(body):
+		e.Body = body
+	case utf8.Valid(body):
+		e.BodyText = string(body)
+	default:
+		e.BodyBase64 = body
+	}
+}
+
+func (e *Episode) body() []byte {
+	switch {
+	case len(e.Body) > 0:
+		return e.Body
+	case e.BodyBase64 != nil:
+		return e.BodyBase64
+	}
+	return []byte(e.BodyText)
+}
+
+type Transport struct {
+	Dir  string
+	Mode Mode
+	// Base does the real requests when recording. nil means http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func New(dir string, mode Mode) *Transport {
+	return &Transport{Dir: dir, Mode: mode}
+}
+
+// Path returns the fixture file for a request, e.g.
+// pokeapi.co/api/v2/location-area@offset=20&limit=20.json
+// URLs with ".." in their host or path are rejected, so nothing is read or
+// written outside Dir.
+func (t *Transport) Path(u *url.URL) (string, error) {
+	name := strings.Trim(u.Path, "/")
+	if name == "" {
+		name = "index"
+	}
+	if u.RawQuery != "" {
+		name += "@" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		name += "#" + u.Fragment
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '*', '?', '"', '<', '>', '|', '\\':
+			return '_'
+		}
+		return r
+	}, name)
+
+	// The query can have slashes too
+	for _, segment := range append([]string{u.Host}, strings.Split(name, "/")...) {
+		if segment == ".." {
+			return "", fmt.Errorf("cassette: unsafe URL %s", u)
+		}
+	}
+
+	return filepath.Join(t.Dir, u.Host, filepath.FromSlash(name)+".json"), nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, err := t.Path(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Mode != Record {
+		episode, err := load(path)
+		if err == nil {
+			return episode.response(req), nil
+		}
+		if t.Mode == Replay {
+			return nil, fmt.Errorf("cassette: no recording for %s %s (%s)", req.Method, req.URL, path)
+		}
+	}
+
+	return t.record(req, path)
+}
+
+func (t *Transport) record(req *http.Request, path string) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	episode := Episode{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: res.StatusCode,
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		episode.Header = http.Header{"Content-Type": {contentType}}
+	}
+	episode.setBody(body)
+
+	err = save(path, episode)
+	if err != nil {
+		return nil, err
+	}
+
+	// The recording may be reformatted; the caller gets the body as it came
+	recorded := episode.response(req)
+	recorded.Body = io.NopCloser(bytes.NewReader(body))
+	recorded.ContentLength = int64(len(body))
+	return recorded, nil
+}
+
+func (e Episode) response(req *http.Request) *http.Response {
+	body := e.body()
+	header := e.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func load(path string) (Episode, error) {
+	episode := Episode{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return episode, err
+	}
+	err = json.Unmarshal(data, &episode)
+	return episode, err
+}
+
+func save(path string, episode Episode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(episode, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,102 @@
+package cassette
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	get := func(mode Mode, path string) (int, string, error) {
+		client := &http.Client{Transport: New(dir, mode)}
+		res, err := client.Get(server.URL + path)
+		if err != nil {
+			return 0, "", err
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body), nil
+	}
+
+	// Nothing recorded yet
+	_, _, err := get(Replay, "/pokemon/pikachu?x=1")
+	if err == nil {
+		t.Fatalf("expected an error replaying without recordings")
+	}
+
+	status, body, err := get(Record, "/pokemon/pikachu?x=1")
+	if err != nil || status != 200 || body != `{"name": "pikachu"}` {
+		t.Fatalf("unexpected recorded response %d %q %v", status, body, err)
+	}
+	get(Auto, "/missing")
+
+	server.Close()
+	// The recording is indented, so compare the decoded JSON
+	status, body, err = get(Replay, "/pokemon/pikachu?x=1")
+	var pokemon struct{ Name string }
+	json.Unmarshal([]byte(body), &pokemon)
+	if err != nil || status != 200 || pokemon.Name != "pikachu" {
+		t.Errorf("unexpected replayed response %d %q %v", status, body, err)
+	}
+	status, _, err = get(Replay, "/missing")
+	if err != nil || status != 404 {
+		t.Errorf("expected a replayed 404, got %d %v", status, err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests to the server, got %d", requests)
+	}
+}
+
+func TestBinaryBody(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+
+	e := Episode{}
+	e.setBody(png)
+	if string(e.body()) != string(png) {
+		t.Errorf("binary body was not kept")
+	}
+	if e.BodyBase64 == nil {
+		t.Errorf("expected binary body in base64")
+	}
+}
+
+func TestUnsafePath(t *testing.T) {
+	tr := New(t.TempDir(), Record)
+	for _, raw := range []string{
+		"https://pokeapi.co/api/../../../etc/passwd",
+		"https://pokeapi.co/api/v2/pokemon?x=/../../../../tmp/x",
+		"https://../api/v2/pokemon",
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tr.Path(u); err == nil {
+			t.Errorf("expected an error for %s", raw)
+		}
+		if _, err := tr.RoundTrip(&http.Request{Method: http.MethodGet, URL: u}); err == nil {
+			t.Errorf("expected RoundTrip to refuse %s", raw)
+		}
+	}
+
+	u, _ := url.Parse("https://pokeapi.co/api/v2/pokemon/mr-mime..x")
+	if _, err := tr.Path(u); err != nil {
+		t.Errorf("unexpected error for a name with dots: %v", err)
+	}
+}
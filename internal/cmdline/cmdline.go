@@ -0,0 +1,178 @@
+// Parsing of the command lines of the REPL, like a shell would:
+//
+//	nickname pikachu "Sir Sparks"      -> [nickname pikachu Sir Sparks]
+//	nickname pikachu Sir\ Sparks       -> the same
+//	map --limit=50                     -> the map command with the option limit=50
+package cmdline
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	ErrTrailingEscape    = errors.New("nothing to escape after \\")
+)
+
+// Split breaks a line into words. Words are separated by spaces, unless they
+// are quoted. Inside double quotes and outside quotes a backslash escapes the
+// next character; inside single quotes everything is literal.
+func Split(line string) ([]string, error) {
+	sp := split(line)
+	if sp.escaped {
+		return nil, ErrTrailingEscape
+	}
+	if sp.quote != 0 {
+		return nil, ErrUnterminatedQuote
+	}
+	return sp.words, nil
+}
+
+// SplitPartial splits a line that is still being typed, e.g. for tab
+// completion: an open quote or a trailing backslash are fine. It returns the
+// words before the last one, the last one (unquoted) and the byte offset
+// where it starts, which is the end of the line after a space.
+//
+//	nickname "Sir Sp    -> [nickname], "Sir Sp", 9
+func SplitPartial(line string) (words []string, last string, start int) {
+	sp := split(line)
+	if !sp.inWord {
+		return sp.words, "", len(line)
+	}
+	return sp.words[:len(sp.words)-1], sp.words[len(sp.words)-1], sp.start
+}
+
+type splitState struct {
+	words []string
+	// Where the last word starts, and whether the line ends inside it
+	start   int
+	inWord  bool
+	quote   rune
+	escaped bool
+}
+
+func split(line string) splitState {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	start := 0
+	quote := rune(0)
+	escaped := false
+
+	for i, r := range line {
+		if !inWord && !unicode.IsSpace(r) {
+			start = i
+		}
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return splitState{words: words, start: start, inWord: inWord, quote: quote, escaped: escaped}
+}
+
+// Args is a parsed command line.
+type Args struct {
+	// The command name, in lowercase. Empty for an empty line.
+	Command string
+	// Positional arguments, as they were written
+	Positional []string
+	// Options: --name=value, or --name alone which is "true"
+	Flags map[string]string
+}
+
+// Parse splits a line and separates the command, the options and the
+// positional arguments. After "--" everything is positional.
+func Parse(line string) (Args, error) {
+	args := Args{Positional: []string{}, Flags: map[string]string{}}
+
+	words, err := Split(line)
+	if err != nil {
+		return args, err
+	}
+	if len(words) == 0 {
+		return args, nil
+	}
+
+	args.Command = strings.ToLower(words[0])
+	options := true
+	for _, word := range words[1:] {
+		if options && word == "--" {
+			options = false
+			continue
+		}
+		if !options || !strings.HasPrefix(word, "--") {
+			args.Positional = append(args.Positional, word)
+			continue
+		}
+		name, value, found := strings.Cut(strings.TrimPrefix(word, "--"), "=")
+		if !found {
+			value = "true"
+		}
+		args.Flags[strings.ToLower(name)] = value
+	}
+
+	return args, nil
+}
+
+// Arg returns the i-th positional argument (from 0), or "" if there is none.
+func (a Args) Arg(i int) string {
+	if i < len(a.Positional) {
+		return a.Positional[i]
+	}
+	return ""
+}
+
+// Flag returns the value of an option and whether it was given.
+func (a Args) Flag(name string) (string, bool) {
+	value, ok := a.Flags[name]
+	return value, ok
+}
+
+// Bool reports whether an option was given without value (or as --name=true).
+func (a Args) Bool(name string) bool {
+	return a.Flags[name] == "true"
+}
+
+// Quote returns word as it has to be written so Split reads it back as one word.
+func Quote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\\\"'") {
+		return word
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(word) + `"`
+}
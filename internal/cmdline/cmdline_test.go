@@ -0,0 +1,110 @@
+package cmdline
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "  hello  world  ", expected: []string{"hello", "world"}},
+		{input: `nickname pikachu "Sir Sparks"`, expected: []string{"nickname", "pikachu", "Sir Sparks"}},
+		{input: `nickname pikachu Sir\ Sparks`, expected: []string{"nickname", "pikachu", "Sir Sparks"}},
+		{input: `say 'no \escapes'`, expected: []string{"say", `no \escapes`}},
+		{input: `say "a \"quoted\" word"`, expected: []string{"say", `a "quoted" word`}},
+		{input: `a""b ''`, expected: []string{"ab", ""}},
+		{input: `--name="Mr. Mime"`, expected: []string{"--name=Mr. Mime"}},
+		{input: "", expected: []string{}},
+	}
+
+	for _, c := range cases {
+		actual, err := Split(c.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.input, err)
+			continue
+		}
+		if !slices.Equal(actual, c.expected) {
+			t.Errorf("%s: expected %q, but got %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected error
+	}{
+		{input: `nickname pikachu "Sir Sparks`, expected: ErrUnterminatedQuote},
+		{input: `nickname 'pikachu`, expected: ErrUnterminatedQuote},
+		{input: `nickname pikachu\`, expected: ErrTrailingEscape},
+	}
+
+	for _, c := range cases {
+		_, err := Split(c.input)
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected %v, but got %v", c.input, c.expected, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	args, err := Parse(`MAP first --Limit=50 --verify -- --not-a-flag "Two Words"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if args.Command != "map" {
+		t.Errorf("Expected command %q, but got %q", "map", args.Command)
+	}
+	expected := []string{"first", "--not-a-flag", "Two Words"}
+	if !slices.Equal(args.Positional, expected) {
+		t.Errorf("Expected %q, but got %q", expected, args.Positional)
+	}
+	if limit, ok := args.Flag("limit"); !ok || limit != "50" {
+		t.Errorf("Expected limit 50, but got %q", limit)
+	}
+	if !args.Bool("verify") || args.Bool("limit") {
+		t.Errorf("Unexpected flags %v", args.Flags)
+	}
+	if args.Arg(2) != "Two Words" || args.Arg(3) != "" {
+		t.Errorf("Unexpected Arg results")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, word := range []string{"pikachu", "Sir Sparks", `say "hi"`, `back\slash`, "it's", ""} {
+		words, err := Split("nickname " + Quote(word))
+		if err != nil || len(words) != 2 || words[1] != word {
+			t.Errorf("%q: expected it back, but got %q (%v)", word, words, err)
+		}
+	}
+}
+
+func TestSplitPartial(t *testing.T) {
+	cases := []struct {
+		line  string
+		words []string
+		last  string
+		start int
+	}{
+		{line: "", words: []string{}, last: "", start: 0},
+		{line: "catch pik", words: []string{"catch"}, last: "pik", start: 6},
+		{line: "catch ", words: []string{"catch"}, last: "", start: 6},
+		{line: `nickname "Sir Sp`, words: []string{"nickname"}, last: "Sir Sp", start: 9},
+		{line: `nickname Sir\ Sp`, words: []string{"nickname"}, last: "Sir Sp", start: 9},
+		{line: `nickname 'it''s`, words: []string{"nickname"}, last: "its", start: 9},
+		{line: `trade offer "big fish" mi`, words: []string{"trade", "offer", "big fish"}, last: "mi", start: 23},
+		{line: `inspect pika\`, words: []string{"inspect"}, last: "pika", start: 8},
+	}
+
+	for _, c := range cases {
+		words, last, start := SplitPartial(c.line)
+		if !slices.Equal(words, c.words) || last != c.last || start != c.start {
+			t.Errorf("%q: expected %q, %q, %d, but got %q, %q, %d", c.line, c.words, c.last, c.start, words, last, start)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// The Pokedex proper: which Pokemon the trainer has seen and which ones were caught.
+package dex
+
+import (
+	"sort"
+	"time"
+)
+
+type Entry struct {
+	// National Dex number. 0 when we don't know it yet.
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Species   string    `json:"species,omitempty"`
+	Types     []string  `json:"types,omitempty"`
+	Seen      bool      `json:"seen"`
+	Caught    bool      `json:"caught"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+type Pokedex struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+func New() *Pokedex {
+	return &Pokedex{
+		Entries: map[string]*Entry{},
+	}
+}
+
+func (d *Pokedex) entry(name string, id int) *Entry {
+	if d.Entries == nil {
+		d.Entries = map[string]*Entry{}
+	}
+
+	e, ok := d.Entries[name]
+	if !ok {
+		e = &Entry{Name: name, FirstSeen: time.Now()}
+		d.Entries[name] = e
+	}
+	if e.ID == 0 {
+		e.ID = id
+	}
+	return e
+}
+
+// MarkSeen records that the trainer came across a Pokemon (exploring or in an encounter).
+func (d *Pokedex) MarkSeen(name string, id int) {
+	e := d.entry(name, id)
+	e.Seen = true
+}
+
+// MarkCaught records a caught Pokemon. Caught Pokemon are always seen too.
+func (d *Pokedex) MarkCaught(name string, id int, species string, types []string) {
+	e := d.entry(name, id)
+	e.Seen = true
+	e.Caught = true
+	if species != "" {
+		e.Species = species
+	}
+	if len(types) > 0 {
+		e.Types = types
+	}
+}
+
+// Get looks an entry up by Pokemon or species name.
+func (d *Pokedex) Get(name string) (Entry, bool) {
+	if e, ok := d.Entries[name]; ok {
+		return *e, true
+	}
+	for _, e := range d.Entries {
+		if e.Species == name {
+			return *e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Sorted returns the entries that pass filter ordered by national Dex number.
+// Entries without a number go at the end, by name. A nil filter keeps everything.
+func (d *Pokedex) Sorted(filter func(Entry) bool) []Entry {
+	entries := []Entry{}
+	for _, e := range d.Entries {
+		if filter == nil || filter(*e) {
+			entries = append(entries, *e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ID == 0 || b.ID == 0 {
+			if a.ID != b.ID {
+				return b.ID == 0
+			}
+			return a.Name < b.Name
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Name < b.Name
+	})
+
+	return entries
+}
+
+// Completion counts how many of the given species have been seen and caught.
+func (d *Pokedex) Completion(species []string) (seen int, caught int) {
+	for _, name := range species {
+		e, ok := d.Get(name)
+		if !ok {
+			continue
+		}
+		if e.Seen {
+			seen++
+		}
+		if e.Caught {
+			caught++
+		}
+	}
+	return seen, caught
+}
+
+func Seen(e Entry) bool {
+	return e.Seen
+}
+
+func Caught(e Entry) bool {
+	return e.Caught
+}
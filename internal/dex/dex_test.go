@@ -0,0 +1,38 @@
+package dex
+
+import "testing"
+
+func TestSortedByNationalNumber(t *testing.T) {
+	d := New()
+	d.MarkSeen("pikachu", 25)
+	d.MarkSeen("mystery", 0)
+	d.MarkCaught("bulbasaur", 1, "bulbasaur", []string{"grass", "poison"})
+	d.MarkSeen("charmander", 4)
+
+	expected := []string{"bulbasaur", "charmander", "pikachu", "mystery"}
+	entries := d.Sorted(nil)
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, but got %d", len(expected), len(entries))
+	}
+	for i := range entries {
+		if entries[i].Name != expected[i] {
+			t.Errorf("Expected %q, but got %q", expected[i], entries[i].Name)
+		}
+	}
+
+	caught := d.Sorted(Caught)
+	if len(caught) != 1 || caught[0].Name != "bulbasaur" {
+		t.Errorf("Expected only bulbasaur to be caught, got %v", caught)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	d := New()
+	d.MarkSeen("pikachu", 25)
+	d.MarkCaught("deoxys-normal", 386, "deoxys", nil)
+
+	seen, caught := d.Completion([]string{"pikachu", "deoxys", "mew"})
+	if seen != 2 || caught != 1 {
+		t.Errorf("Expected 2 seen and 1 caught, but got %d and %d", seen, caught)
+	}
+}
@@ -0,0 +1,128 @@
+// Gyms of each region, their leaders and the badges they give. Every region
+// is a file in regions/, with the gyms in the order they're challenged.
+package gym
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/neixir/pokedex/internal/battle"
+)
+
+//go:embed regions
+var regions embed.FS
+
+// Member is a Pokemon of the team of a leader.
+type Member struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+type Gym struct {
+	Leader string `json:"leader"`
+	Badge  string `json:"badge"`
+	// The type the leader specializes in
+	Type string `json:"type"`
+	// The PokeAPI location of the gym, e.g. "oreburgh-city"
+	Location string `json:"location"`
+	// Highest level Pokemon fight at once the badge is earned
+	LevelCap int      `json:"level_cap"`
+	Team     []Member `json:"team"`
+}
+
+type Region struct {
+	Name       string `json:"name"`
+	Generation int    `json:"generation"`
+	// Highest level Pokemon fight at without any badge of the region
+	LevelCap int   `json:"level_cap"`
+	Gyms     []Gym `json:"gyms"`
+}
+
+// Load reads the regions of fsys, one JSON file each, by generation.
+func Load(fsys fs.FS) ([]Region, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	all := []Region{}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		r := Region{}
+		err = json.Unmarshal(data, &r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Base(file), err)
+		}
+		all = append(all, r)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Generation < all[j].Generation })
+	return all, nil
+}
+
+// Regions are the bundled regions.
+func Regions() []Region {
+	sub, err := fs.Sub(regions, "regions")
+	if err != nil {
+		panic(err)
+	}
+	all, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return all
+}
+
+// At returns the gym at location, and its region.
+func At(regions []Region, location string) (Region, Gym, bool) {
+	for _, r := range regions {
+		for _, g := range r.Gyms {
+			if g.Location == location {
+				return r, g, true
+			}
+		}
+	}
+	return Region{}, Gym{}, false
+}
+
+// Next is the gym of the region to challenge with the given badges, false
+// when all of them have been beaten.
+func (r Region) Next(badges []string) (Gym, bool) {
+	for _, g := range r.Gyms {
+		if !slices.Contains(badges, g.Badge) {
+			return g, true
+		}
+	}
+	return Gym{}, false
+}
+
+// Cap is the level cap in the region with the given badges.
+func (r Region) Cap(badges []string) int {
+	levelCap := r.LevelCap
+	for _, g := range r.Gyms {
+		if slices.Contains(badges, g.Badge) {
+			levelCap = max(levelCap, g.LevelCap)
+		}
+	}
+	return levelCap
+}
+
+// LevelCap is the highest level Pokemon fight at with the given badges:
+// the highest cap of any region.
+func LevelCap(regions []Region, badges []string) int {
+	levelCap := 0
+	for _, r := range regions {
+		levelCap = max(levelCap, r.Cap(badges))
+	}
+	if levelCap == 0 {
+		return battle.MaxLevel
+	}
+	return levelCap
+}
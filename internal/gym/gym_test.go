@@ -0,0 +1,51 @@
+package gym
+
+import (
+	"testing"
+)
+
+func TestRegions(t *testing.T) {
+	all := Regions()
+	if len(all) != 2 || all[0].Name != "kanto" || all[1].Name != "sinnoh" {
+		t.Fatalf("expected kanto and sinnoh, got %+v", all)
+	}
+
+	badges := map[string]bool{}
+	for _, r := range all {
+		if len(r.Gyms) != 8 {
+			t.Errorf("expected 8 gyms in %s, got %d", r.Name, len(r.Gyms))
+		}
+		levelCap := r.LevelCap
+		for _, g := range r.Gyms {
+			if badges[g.Badge] {
+				t.Errorf("badge %s given twice", g.Badge)
+			}
+			badges[g.Badge] = true
+			if g.Leader == "" || g.Type == "" || g.Location == "" || len(g.Team) == 0 {
+				t.Errorf("incomplete gym %+v", g)
+			}
+			if g.LevelCap < levelCap {
+				t.Errorf("expected the level cap to grow with every badge, %s gives %d", g.Badge, g.LevelCap)
+			}
+			levelCap = g.LevelCap
+		}
+	}
+}
+
+func TestLevelCap(t *testing.T) {
+	all := Regions()
+	if c := LevelCap(all, nil); c != 15 {
+		t.Errorf("expected a cap of 15 without badges, got %d", c)
+	}
+	if c := LevelCap(all, []string{"boulder-badge", "cascade-badge", "coal-badge"}); c != 24 {
+		t.Errorf("expected a cap of 24, got %d", c)
+	}
+
+	r, g, ok := At(all, "pastoria-city")
+	if !ok || r.Name != "sinnoh" || g.Leader != "crasher-wake" {
+		t.Errorf("expected crasher-wake in pastoria-city, got %+v", g)
+	}
+	if next, _ := r.Next([]string{"coal-badge"}); next.Badge != "forest-badge" {
+		t.Errorf("expected the forest-badge next, got %s", next.Badge)
+	}
+}
@@ -0,0 +1,496 @@
+// A small line editor for the REPL: cursor movement, persistent history,
+// reverse search (Ctrl-R) and tab completion.
+// Only ANSI terminals are supported; the line is assumed to fit in one row.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+const MaxHistory = 1000
+
+// Completer gets the line up to the cursor and returns where the word being completed
+// starts, in runes, and the possible completions for it.
+type Completer func(line string) (start int, candidates []string)
+
+type Editor struct {
+	Completer   Completer
+	history     []string
+	historyPath string
+	in          *os.File
+	out         io.Writer
+	reader      *bufio.Reader
+}
+
+// New creates an editor reading from stdin. History is loaded from (and saved to) historyPath;
+// an empty path disables persistent history.
+func New(historyPath string) *Editor {
+	e := &Editor{
+		historyPath: historyPath,
+		in:          os.Stdin,
+		out:         os.Stdout,
+		reader:      bufio.NewReader(os.Stdin),
+	}
+	e.loadHistory()
+	return e
+}
+
+// IsTerminal reports whether stdin is a terminal, i.e. whether line editing makes sense.
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ReadLine shows the prompt and returns the line typed by the user.
+// It returns io.EOF on Ctrl-D with an empty line and ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	return e.readLine(e.reader, e.out, prompt)
+}
+
+// History returns the lines entered so far, oldest first.
+func (e *Editor) History() []string {
+	return append([]string{}, e.history...)
+}
+
+// AddHistory remembers a line and appends it to the history file.
+func (e *Editor) AddHistory(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+
+	e.history = append(e.history, line)
+	if len(e.history) > MaxHistory {
+		e.history = e.history[len(e.history)-MaxHistory:]
+	}
+
+	if e.historyPath == "" {
+		return
+	}
+	os.MkdirAll(filepath.Dir(e.historyPath), 0o755)
+	f, err := os.OpenFile(e.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func (e *Editor) loadHistory() {
+	if e.historyPath == "" {
+		return
+	}
+	data, err := os.ReadFile(e.historyPath)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > MaxHistory {
+		e.history = e.history[len(e.history)-MaxHistory:]
+		// Rewrite the file so it doesn't grow forever
+		os.WriteFile(e.historyPath, []byte(strings.Join(e.history, "\n")+"\n"), 0o600)
+	}
+}
+
+// Key codes
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Made-up codes for escape sequences, outside of the Unicode range
+const (
+	keyUp = unicode.MaxRune + 1 + iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDeleteForward
+	keyUnknown
+)
+
+// Keys returned by ReadKey, besides the runes typed and the control characters.
+const (
+	KeyUp     = keyUp
+	KeyDown   = keyDown
+	KeyLeft   = keyLeft
+	KeyRight  = keyRight
+	KeyHome   = keyHome
+	KeyEnd    = keyEnd
+	KeyEscape = keyEscape
+	KeyEnter  = keyEnter
+	KeyTab    = keyTab
+	KeyCtrlC  = keyCtrlC
+)
+
+// ReadKey reads one key from a terminal in raw mode, for programs that
+// handle the keyboard themselves.
+func ReadKey(r *bufio.Reader) (rune, error) {
+	return readKey(r)
+}
+
+// readKey reads one key, decoding the escape sequences of arrows, home, end and delete.
+func readKey(r *bufio.Reader) (rune, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if c != keyEscape {
+		return c, nil
+	}
+
+	// A lone escape: nothing else is waiting
+	if r.Buffered() == 0 {
+		return keyEscape, nil
+	}
+
+	c, _, err = r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if c != '[' && c != 'O' {
+		return keyUnknown, nil
+	}
+
+	c, _, err = r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch c {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+
+	// ESC [ n ~
+	if c >= '0' && c <= '9' {
+		n := c
+		for {
+			c, _, err = r.ReadRune()
+			if err != nil {
+				return 0, err
+			}
+			if c == '~' {
+				break
+			}
+			if c < '0' || c > '9' {
+				return keyUnknown, nil
+			}
+		}
+		switch n {
+		case '1', '7':
+			return keyHome, nil
+		case '4', '8':
+			return keyEnd, nil
+		case '3':
+			return keyDeleteForward, nil
+		}
+	}
+
+	return keyUnknown, nil
+}
+
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+	out    io.Writer
+}
+
+func (s *lineState) refresh() {
+	fmt.Fprintf(s.out, "\r%s%s\x1b[K", s.prompt, string(s.buf))
+	if back := len(s.buf) - s.pos; back > 0 {
+		fmt.Fprintf(s.out, "\x1b[%dD", back)
+	}
+}
+
+func (s *lineState) set(line string) {
+	s.buf = []rune(line)
+	s.pos = len(s.buf)
+}
+
+func (s *lineState) insert(r rune) {
+	s.buf = append(s.buf[:s.pos], append([]rune{r}, s.buf[s.pos:]...)...)
+	s.pos++
+}
+
+func (e *Editor) readLine(r *bufio.Reader, w io.Writer, prompt string) (string, error) {
+	s := &lineState{prompt: prompt, out: w}
+	// Index in history while browsing with up/down; len(history) is the line being edited
+	histIndex := len(e.history)
+	editing := ""
+	lastWasTab := false
+
+	s.refresh()
+	for {
+		key, err := readKey(r)
+		if err != nil {
+			return "", err
+		}
+
+		tab := key == keyTab
+		switch key {
+		case keyEnter, keyLF:
+			fmt.Fprint(w, "\r\n")
+			return string(s.buf), nil
+
+		case keyCtrlC:
+			fmt.Fprint(w, "^C\r\n")
+			return "", ErrInterrupted
+
+		case keyCtrlD:
+			if len(s.buf) == 0 {
+				fmt.Fprint(w, "\r\n")
+				return "", io.EOF
+			}
+			if s.pos < len(s.buf) {
+				s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+			}
+
+		case keyBackspace, keyDelete:
+			if s.pos > 0 {
+				s.buf = append(s.buf[:s.pos-1], s.buf[s.pos:]...)
+				s.pos--
+			}
+
+		case keyDeleteForward:
+			if s.pos < len(s.buf) {
+				s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+			}
+
+		case keyLeft, keyCtrlB:
+			if s.pos > 0 {
+				s.pos--
+			}
+
+		case keyRight, keyCtrlF:
+			if s.pos < len(s.buf) {
+				s.pos++
+			}
+
+		case keyHome, keyCtrlA:
+			s.pos = 0
+
+		case keyEnd, keyCtrlE:
+			s.pos = len(s.buf)
+
+		case keyCtrlK:
+			s.buf = s.buf[:s.pos]
+
+		case keyCtrlU:
+			s.buf = s.buf[s.pos:]
+			s.pos = 0
+
+		case keyCtrlW:
+			start := s.pos
+			for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+			s.pos = start
+
+		case keyCtrlL:
+			fmt.Fprint(w, "\x1b[H\x1b[2J")
+
+		case keyUp, keyCtrlP:
+			if histIndex > 0 {
+				if histIndex == len(e.history) {
+					editing = string(s.buf)
+				}
+				histIndex--
+				s.set(e.history[histIndex])
+			}
+
+		case keyDown, keyCtrlN:
+			if histIndex < len(e.history) {
+				histIndex++
+				if histIndex == len(e.history) {
+					s.set(editing)
+				} else {
+					s.set(e.history[histIndex])
+				}
+			}
+
+		case keyCtrlR:
+			line, accept, err := e.reverseSearch(r, s)
+			if err != nil {
+				return "", err
+			}
+			s.prompt = prompt
+			s.set(line)
+			if accept {
+				s.refresh()
+				fmt.Fprint(w, "\r\n")
+				return line, nil
+			}
+
+		case keyTab:
+			e.complete(s, lastWasTab)
+
+		case keyEscape, keyUnknown, keyCtrlG:
+			// ignore
+
+		default:
+			if unicode.IsPrint(key) {
+				s.insert(key)
+			}
+		}
+
+		lastWasTab = tab
+		s.refresh()
+	}
+}
+
+// reverseSearch implements Ctrl-R. It returns the chosen line and whether it should be
+// executed straight away (Enter) or just left in the buffer for editing.
+func (e *Editor) reverseSearch(r *bufio.Reader, s *lineState) (string, bool, error) {
+	original := string(s.buf)
+	query := []rune{}
+	match := ""
+	index := len(e.history)
+
+	search := func(from int) bool {
+		for i := from; i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				match = e.history[i]
+				index = i
+				return true
+			}
+		}
+		return false
+	}
+
+	for {
+		s.prompt = fmt.Sprintf("(reverse-i-search)`%s': ", string(query))
+		s.set(match)
+		s.refresh()
+
+		key, err := readKey(r)
+		if err != nil {
+			return "", false, err
+		}
+
+		switch key {
+		case keyEnter, keyLF:
+			return match, true, nil
+		case keyCtrlC, keyCtrlG:
+			return original, false, nil
+		case keyCtrlR:
+			search(index - 1)
+		case keyBackspace, keyDelete:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				if !search(len(e.history) - 1) {
+					match = ""
+				}
+			}
+		default:
+			if unicode.IsPrint(key) {
+				query = append(query, key)
+				if !search(min(index, len(e.history)-1)) {
+					match = ""
+				}
+				continue
+			}
+			// Any other key leaves the match in the buffer for editing
+			return match, false, nil
+		}
+	}
+}
+
+// complete handles Tab. One candidate completes the word; several complete their
+// common prefix, and a second Tab lists them.
+func (e *Editor) complete(s *lineState, listAll bool) {
+	if e.Completer == nil {
+		return
+	}
+
+	start, candidates := e.Completer(string(s.buf[:s.pos]))
+	if len(candidates) == 0 || start < 0 || start > s.pos {
+		return
+	}
+
+	word := string(s.buf[start:s.pos])
+	replacement := candidates[0] + " "
+	if len(candidates) > 1 {
+		replacement = commonPrefix(candidates)
+	}
+
+	if len(candidates) > 1 && replacement == word {
+		if listAll {
+			fmt.Fprintf(s.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+		}
+		return
+	}
+
+	rest := s.buf[s.pos:]
+	s.buf = append(append(append([]rune{}, s.buf[:start]...), []rune(replacement)...), rest...)
+	s.pos = start + len([]rune(replacement))
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
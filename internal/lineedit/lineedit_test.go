@@ -0,0 +1,97 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestEditor(history ...string) *Editor {
+	return &Editor{history: history}
+}
+
+func TestReadLineEditing(t *testing.T) {
+	cases := []struct {
+		name     string
+		keys     string
+		history  []string
+		expected string
+	}{
+		{name: "plain", keys: "map\r", expected: "map"},
+		{name: "backspace", keys: "mapx\x7f\r", expected: "map"},
+		{name: "left and insert", keys: "mp\x1b[Da\r", expected: "map"},
+		{name: "home and end", keys: "ap\x01m\x05b\r", expected: "mapb"},
+		{name: "delete word", keys: "catch pikachu\x17mew\r", expected: "catch mew"},
+		{name: "history up", keys: "\x1b[A\x1b[A\r", history: []string{"map", "mapb"}, expected: "map"},
+		{name: "history up and down", keys: "ex\x1b[A\x1b[B\r", history: []string{"map"}, expected: "ex"},
+		{name: "reverse search", keys: "\x12pika\r", history: []string{"catch pikachu", "map"}, expected: "catch pikachu"},
+		{name: "reverse search older", keys: "\x12ca\x12\r", history: []string{"catch mew", "catch pikachu"}, expected: "catch mew"},
+		{name: "reverse search then edit", keys: "\x12map\x1b[Db\r", history: []string{"map"}, expected: "mapb"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := newTestEditor(c.history...)
+			line, err := e.readLine(bufio.NewReader(strings.NewReader(c.keys)), io.Discard, "> ")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if line != c.expected {
+				t.Errorf("Expected %q, but got %q", c.expected, line)
+			}
+		})
+	}
+}
+
+func TestReadLineEOFAndInterrupt(t *testing.T) {
+	e := newTestEditor()
+
+	_, err := e.readLine(bufio.NewReader(strings.NewReader("\x04")), io.Discard, "> ")
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	_, err = e.readLine(bufio.NewReader(strings.NewReader("map\x03")), io.Discard, "> ")
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected ErrInterrupted, got %v", err)
+	}
+}
+
+func TestTabCompletion(t *testing.T) {
+	e := newTestEditor()
+	e.Completer = func(line string) (int, []string) {
+		start := strings.LastIndex(line, " ") + 1
+		candidates := []string{}
+		for _, name := range []string{"pikachu", "pichu", "mew", "flabébé", "flabèbè"} {
+			if strings.HasPrefix(name, line[start:]) {
+				candidates = append(candidates, name)
+			}
+		}
+		return utf8.RuneCountInString(line[:start]), candidates
+	}
+
+	cases := []struct {
+		keys     string
+		expected string
+	}{
+		{keys: "catch m\t\r", expected: "catch mew "},
+		{keys: "catch p\t\r", expected: "catch pi"},
+		{keys: "catch pik\t\r", expected: "catch pikachu "},
+		// Multibyte text before the word, and candidates that differ in a multibyte rune
+		{keys: "nickname éé fl\t\r", expected: "nickname éé flab"},
+		{keys: "nickname éé flabé\t\r", expected: "nickname éé flabébé "},
+	}
+
+	for _, c := range cases {
+		line, err := e.readLine(bufio.NewReader(strings.NewReader(c.keys)), io.Discard, "> ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if line != c.expected {
+			t.Errorf("Expected %q, but got %q", c.expected, line)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Offline mirror of PokeAPI on disk.
+// "sync" downloads whole resources (every Pokemon, type, move...) into
+// <dir>/<resource>/<name>.json and keeps a manifest with the checksum of every file,
+// so an interrupted sync can resume and a damaged mirror can be detected.
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ManifestFile = "manifest.json"
+
+// DefaultResources are the resources synced when none are given.
+var DefaultResources = []string{"pokemon", "pokemon-species", "type", "move", "location-area"}
+
+type Entry struct {
+	ID     int    `json:"id"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+type Resource struct {
+	// Complete is set when every item of the resource list has been downloaded.
+	Complete bool             `json:"complete"`
+	Count    int              `json:"count"`
+	Entries  map[string]Entry `json:"entries"`
+}
+
+type Manifest struct {
+	Version   int                  `json:"version"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Resources map[string]*Resource `json:"resources"`
+}
+
+type Store struct {
+	Dir string
+
+	mu       sync.Mutex
+	manifest Manifest
+}
+
+// Open loads the mirror in dir. A directory without manifest is an empty mirror.
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		Dir:      dir,
+		manifest: Manifest{Version: 1, Resources: map[string]*Resource{}},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &s.manifest)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt mirror manifest: %w", err)
+	}
+	if s.manifest.Resources == nil {
+		s.manifest.Resources = map[string]*Resource{}
+	}
+	return s, nil
+}
+
+func (s *Store) path(resource, name string) string {
+	return filepath.Join(s.Dir, resource, name+".json")
+}
+
+// resource returns the manifest of a resource, creating it. s.mu must be held.
+func (s *Store) resource(name string) *Resource {
+	r, ok := s.manifest.Resources[name]
+	if !ok {
+		r = &Resource{Entries: map[string]Entry{}}
+		s.manifest.Resources[name] = r
+	}
+	if r.Entries == nil {
+		r.Entries = map[string]Entry{}
+	}
+	return r
+}
+
+// lookup resolves a name or id to the name of an entry.
+func (s *Store) lookup(resource, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.manifest.Resources[resource]
+	if !ok {
+		return "", false
+	}
+	if _, ok := r.Entries[key]; ok {
+		return key, true
+	}
+	if id, err := strconv.Atoi(key); err == nil {
+		for name, e := range r.Entries {
+			if e.ID == id {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Has reports whether the mirror has an entry.
+func (s *Store) Has(resource, name string) bool {
+	_, ok := s.lookup(resource, name)
+	return ok
+}
+
+// Names returns the names of the entries of a resource, sorted.
+func (s *Store) Names(resource string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := []string{}
+	if r, ok := s.manifest.Resources[resource]; ok {
+		for name := range r.Entries {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read returns the JSON of an entry.
+func (s *Store) Read(resource string, name string) ([]byte, error) {
+	return os.ReadFile(s.path(resource, name))
+}
+
+// Stats returns how many entries each resource has, and whether it is complete.
+func (s *Store) Stats() map[string]Resource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := map[string]Resource{}
+	for name, r := range s.manifest.Resources {
+		stats[name] = Resource{Complete: r.Complete, Count: len(r.Entries)}
+	}
+	return stats
+}
+
+// Get answers a PokeAPI URL from the mirror: a single resource (by name or id)
+// or, for complete resources, a page of the resource list.
+func (s *Store) Get(url string) ([]byte, bool) {
+	i := strings.Index(url, "/api/v2/")
+	if i < 0 {
+		return nil, false
+	}
+	base := url[:i+len("/api/v2/")]
+	path, query, _ := strings.Cut(url[len(base):], "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch len(parts) {
+	case 1:
+		return s.listPage(base, parts[0], query)
+	case 2:
+		name, ok := s.lookup(parts[0], parts[1])
+		if !ok {
+			return nil, false
+		}
+		body, err := os.ReadFile(s.path(parts[0], name))
+		if err != nil {
+			return nil, false
+		}
+		return body, true
+	}
+	return nil, false
+}
+
+type namedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type resourceList struct {
+	Count    int             `json:"count"`
+	Next     *string         `json:"next"`
+	Previous *string         `json:"previous"`
+	Results  []namedResource `json:"results"`
+}
+
+// listPage builds a page of a resource list like PokeAPI would (sorted by id, offset/limit).
+func (s *Store) listPage(base, resource, query string) ([]byte, bool) {
+	s.mu.Lock()
+	r, ok := s.manifest.Resources[resource]
+	if !ok || !r.Complete {
+		s.mu.Unlock()
+		return nil, false
+	}
+	type item struct {
+		name string
+		id   int
+	}
+	items := []item{}
+	for name, e := range r.Entries {
+		items = append(items, item{name, e.ID})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].id < items[j].id
+	})
+
+	offset, limit := 0, 20
+	for _, param := range strings.Split(query, "&") {
+		key, value, _ := strings.Cut(param, "=")
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "offset":
+			offset = max(n, 0)
+		case "limit":
+			if n > 0 {
+				limit = n
+			}
+		}
+	}
+
+	page := resourceList{Count: len(items), Results: []namedResource{}}
+	for i := offset; i < len(items) && i < offset+limit; i++ {
+		page.Results = append(page.Results, namedResource{
+			Name: items[i].name,
+			URL:  fmt.Sprintf("%s%s/%d/", base, resource, items[i].id),
+		})
+	}
+	if offset+limit < len(items) {
+		next := fmt.Sprintf("%s%s/?offset=%d&limit=%d", base, resource, offset+limit, limit)
+		page.Next = &next
+	}
+	if offset > 0 {
+		previous := fmt.Sprintf("%s%s/?offset=%d&limit=%d", base, resource, max(offset-limit, 0), limit)
+		page.Previous = &previous
+	}
+
+	body, err := json.Marshal(page)
+	return body, err == nil
+}
+
+// put writes an entry to disk and records it in the manifest.
+func (s *Store) put(resource, name string, body []byte) error {
+	var header struct {
+		ID int `json:"id"`
+	}
+	err := json.Unmarshal(body, &header)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", resource, name, err)
+	}
+
+	path := s.path(resource, name)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, body, 0o644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	s.mu.Lock()
+	s.resource(resource).Entries[name] = Entry{
+		ID:     header.ID,
+		SHA256: hex.EncodeToString(sum[:]),
+		Size:   len(body),
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// SaveManifest writes the manifest (atomically).
+func (s *Store) SaveManifest() error {
+	s.mu.Lock()
+	s.manifest.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s.manifest, "", " ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(s.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.Dir, ManifestFile)
+	err = os.WriteFile(path+".tmp", data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Verify checks every file against the manifest. Damaged or missing files are
+// removed (so the next sync downloads them again) and returned.
+func (s *Store) Verify() ([]string, error) {
+	problems := []string{}
+
+	s.mu.Lock()
+	for resource, r := range s.manifest.Resources {
+		for name, e := range r.Entries {
+			body, err := os.ReadFile(s.path(resource, name))
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s/%s: missing", resource, name))
+			} else if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != e.SHA256 {
+				problems = append(problems, fmt.Sprintf("%s/%s: checksum mismatch", resource, name))
+			} else {
+				continue
+			}
+			os.Remove(s.path(resource, name))
+			delete(r.Entries, name)
+			r.Complete = false
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(problems)
+	if len(problems) == 0 {
+		return problems, nil
+	}
+	return problems, s.SaveManifest()
+}
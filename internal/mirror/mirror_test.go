@@ -0,0 +1,152 @@
+package mirror
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neixir/pokedex/internal/mockapi"
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+func newTestStore(t *testing.T) *Store {
+	s, err := mockapi.New(mockapi.Fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	pokeapi.SetBaseURL(server.URL + "/api/v2/")
+	pokeapi.DebugOutput = io.Discard
+	t.Cleanup(func() { pokeapi.SetBaseURL(pokeapi.DefaultBaseURL) })
+
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSyncAndResume(t *testing.T) {
+	store := newTestStore(t)
+
+	reports, err := store.Sync([]string{"type", "pokemon"}, Options{Jobs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reports {
+		if r.Total == 0 || r.Downloaded != r.Total || r.Failed != 0 {
+			t.Errorf("Expected %s to be fully downloaded, but got %+v", r.Resource, r)
+		}
+	}
+
+	// Files written by an interrupted sync (not in the manifest) are adopted
+	reopened, err := Open(store.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.manifest.Resources["pokemon"].Entries = map[string]Entry{}
+	reports, err = reopened.Sync([]string{"type", "pokemon"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reports {
+		if r.Downloaded != 0 || r.Skipped != r.Total {
+			t.Errorf("Expected %s to be skipped on resume, but got %+v", r.Resource, r)
+		}
+	}
+}
+
+func TestSyncUnknownResource(t *testing.T) {
+	store := newTestStore(t)
+
+	reports, err := store.Sync([]string{"move", "type"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports[0].Errors) == 0 {
+		t.Errorf("Expected an error listing move, but got %+v", reports[0])
+	}
+	if reports[1].Downloaded == 0 {
+		t.Errorf("Expected type to be synced after move failed, but got %+v", reports[1])
+	}
+}
+
+func TestGet(t *testing.T) {
+	store := newTestStore(t)
+	_, err := store.Sync([]string{"pokemon"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := "https://pokeapi.co/api/v2/"
+	cases := []struct {
+		url  string
+		name string
+	}{
+		{base + "pokemon/pikachu", "pikachu"},
+		{base + "pokemon/25/", "pikachu"},
+		{base + "pokemon/missingno", ""},
+		{base + "type/fire", ""},
+	}
+	for _, c := range cases {
+		body, ok := store.Get(c.url)
+		if ok != (c.name != "") {
+			t.Errorf("Expected %s found=%v, but got %v", c.url, c.name != "", ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		var pokemon struct{ Name string }
+		json.Unmarshal(body, &pokemon)
+		if pokemon.Name != c.name {
+			t.Errorf("Expected %q, but got %q", c.name, pokemon.Name)
+		}
+	}
+
+	body, ok := store.Get(base + "pokemon/?offset=20&limit=20")
+	if !ok {
+		t.Fatal("Expected a list page of a complete resource")
+	}
+	page := resourceList{}
+	json.Unmarshal(body, &page)
+	if page.Count != 22 || len(page.Results) != 2 || page.Next != nil || page.Previous == nil {
+		t.Errorf("Unexpected page: count %d, %d results", page.Count, len(page.Results))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	store := newTestStore(t)
+	_, err := store.Sync([]string{"type"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(store.Dir, "type", "fire.json"), []byte(`{"id": 10}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := store.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0] != "type/fire: checksum mismatch" {
+		t.Errorf("Expected fire to be damaged, but got %q", problems)
+	}
+	if store.Has("type", "fire") {
+		t.Errorf("Expected fire to be removed from the manifest")
+	}
+
+	reports, err := store.Sync([]string{"type"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports[0].Downloaded != 1 {
+		t.Errorf("Expected fire to be downloaded again, but got %+v", reports[0])
+	}
+}
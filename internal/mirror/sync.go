@@ -0,0 +1,150 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// How often (in downloaded entries) the manifest is saved during a sync,
+// which is how much work is lost if it is interrupted.
+const checkpointEvery = 50
+
+// Page size used to walk the resource lists.
+const listPageSize = 100
+
+type Options struct {
+	// Concurrent downloads. 0 means 8.
+	Jobs int
+	// Progress is called after every entry (from several goroutines, one at a time).
+	Progress func(resource string, done, total int)
+}
+
+type Report struct {
+	Resource   string   `json:"resource"`
+	Total      int      `json:"total"`
+	Downloaded int      `json:"downloaded"`
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Sync downloads every entry of the given resources that the mirror doesn't have yet.
+func (s *Store) Sync(resources []string, opts Options) ([]Report, error) {
+	if opts.Jobs <= 0 {
+		opts.Jobs = 8
+	}
+
+	reports := []Report{}
+	for _, resource := range resources {
+		report, err := s.syncResource(resource, opts)
+		reports = append(reports, report)
+		if err != nil {
+			return reports, err
+		}
+	}
+	return reports, nil
+}
+
+// listAll walks a resource list following the Next URLs.
+func listAll(resource string) ([]pokeapi.NamedAPIResource, error) {
+	entries := []pokeapi.NamedAPIResource{}
+	for r, err := range pokeapi.Resources(pokeapi.ListURL(resource, 0, listPageSize), nil) {
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", resource, err)
+		}
+		entries = append(entries, r)
+	}
+	return entries, nil
+}
+
+func (s *Store) syncResource(resource string, opts Options) (Report, error) {
+	report := Report{Resource: resource}
+
+	// A resource that can't be listed (e.g. the server doesn't have it)
+	// doesn't stop the others
+	entries, err := listAll(resource)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report, nil
+	}
+	report.Total = len(entries)
+
+	pending := []pokeapi.NamedAPIResource{}
+	for _, e := range entries {
+		if s.Has(resource, e.Name) || s.adopt(resource, e.Name) {
+			report.Skipped++
+		} else {
+			pending = append(pending, e)
+		}
+	}
+
+	var mu sync.Mutex
+	done := report.Skipped
+	if opts.Progress != nil {
+		opts.Progress(resource, done, report.Total)
+	}
+
+	jobs := make(chan pokeapi.NamedAPIResource)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				body, err := pokeapi.Download(e.URL, resource)
+				if err == nil {
+					err = s.put(resource, e.Name, body)
+				}
+
+				mu.Lock()
+				if err != nil {
+					report.Failed++
+					report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", e.Name, err))
+				} else {
+					report.Downloaded++
+					if report.Downloaded%checkpointEvery == 0 {
+						// Without the manifest an interrupted sync starts over, so say why
+						if err := s.SaveManifest(); err != nil {
+							report.Errors = append(report.Errors, fmt.Sprintf("saving the manifest: %v", err))
+						}
+					}
+				}
+				done++
+				if opts.Progress != nil {
+					opts.Progress(resource, done, report.Total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, e := range pending {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.mu.Lock()
+	r := s.resource(resource)
+	r.Count = report.Total
+	r.Complete = report.Failed == 0
+	s.mu.Unlock()
+
+	return report, s.SaveManifest()
+}
+
+// adopt takes a file downloaded by an interrupted sync (written, but the
+// manifest wasn't saved afterwards) into the manifest.
+func (s *Store) adopt(resource, name string) bool {
+	body, err := os.ReadFile(s.path(resource, name))
+	if err != nil {
+		return false
+	}
+	if !json.Valid(body) {
+		return false
+	}
+	return s.put(resource, name, body) == nil
+}
@@ -0,0 +1,205 @@
+// A local stand-in for PokeAPI, serving bundled fixture JSON.
+// Fixtures live in <resource>/<name>.json (e.g. pokemon/pikachu.json) and can be
+// requested by name or by id, like on pokeapi.co. List endpoints are paginated
+// with offset/limit and count/next/previous exactly as PokeAPI does.
+package mockapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Fixtures returns the bundled fixtures.
+func Fixtures() fs.FS {
+	sub, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// URLs inside the fixtures point here; they are rewritten to the mock server.
+const upstream = "https://pokeapi.co/api/v2/"
+
+const DefaultLimit = 20
+
+type resource struct {
+	Name string
+	ID   int
+	body []byte
+}
+
+type Server struct {
+	// resource kind (pokemon, type...) -> resources sorted by id
+	resources map[string][]resource
+}
+
+// New loads every fixture of fsys.
+func New(fsys fs.FS) (*Server, error) {
+	s := &Server{resources: map[string][]resource{}}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".json" {
+			return nil
+		}
+
+		kind := path.Dir(p)
+		body, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var header struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		err = json.Unmarshal(body, &header)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if header.Name == "" {
+			header.Name = strings.TrimSuffix(path.Base(p), ".json")
+		}
+
+		s.resources[kind] = append(s.resources[kind], resource{Name: header.Name, ID: header.ID, body: body})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, list := range s.resources {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].ID < list[j].ID
+		})
+	}
+
+	return s, nil
+}
+
+// Kinds returns the resource kinds being served, sorted.
+func (s *Server) Kinds() []string {
+	kinds := []string{}
+	for kind := range s.resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/api/v2/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	base := baseURL(r)
+
+	switch len(parts) {
+	case 1:
+		s.serveList(w, r, base, parts[0])
+	case 2:
+		s.serveResource(w, r, base, parts[0], parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// baseURL is the address the client used to reach us, e.g. http://localhost:8080/api/v2/
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/v2/", scheme, r.Host)
+}
+
+type namedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type resourceList struct {
+	Count    int             `json:"count"`
+	Next     *string         `json:"next"`
+	Previous *string         `json:"previous"`
+	Results  []namedResource `json:"results"`
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, base, kind string) {
+	list, ok := s.resources[kind]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	offset := queryInt(r, "offset", 0)
+	limit := queryInt(r, "limit", DefaultLimit)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	page := resourceList{Count: len(list), Results: []namedResource{}}
+	for i := offset; i < len(list) && i < offset+limit; i++ {
+		page.Results = append(page.Results, namedResource{
+			Name: list[i].Name,
+			URL:  fmt.Sprintf("%s%s/%d/", base, kind, list[i].ID),
+		})
+	}
+	if offset+limit < len(list) {
+		next := fmt.Sprintf("%s%s/?offset=%d&limit=%d", base, kind, offset+limit, limit)
+		page.Next = &next
+	}
+	if offset > 0 {
+		previous := fmt.Sprintf("%s%s/?offset=%d&limit=%d", base, kind, max(offset-limit, 0), limit)
+		page.Previous = &previous
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, base, kind, key string) {
+	id, err := strconv.Atoi(key)
+	for _, res := range s.resources[kind] {
+		if res.Name == key || (err == nil && res.ID == id) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(strings.ReplaceAll(string(res.body), upstream, base)))
+			return
+		}
+	}
+
+	// PokeAPI answers a plain "Not Found"
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte("Not Found"))
+}
+
+func queryInt(r *http.Request, name string, fallback int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
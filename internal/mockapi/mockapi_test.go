@@ -0,0 +1,84 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	s, err := New(Fixtures())
+	if err != nil {
+		t.Fatalf("could not load fixtures: %v", err)
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getList(t *testing.T, url string) resourceList {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	list := resourceList{}
+	json.NewDecoder(res.Body).Decode(&list)
+	return list
+}
+
+func TestPagination(t *testing.T) {
+	server := newTestServer(t)
+
+	first := getList(t, server.URL+"/api/v2/location-area/")
+	if first.Count != 20 || len(first.Results) != 20 || first.Next != nil || first.Previous != nil {
+		t.Errorf("unexpected first page: count %d, %d results", first.Count, len(first.Results))
+	}
+	if first.Results[0].Name != "canalave-city-area" || first.Results[0].URL != server.URL+"/api/v2/location-area/1/" {
+		t.Errorf("unexpected first result %+v", first.Results[0])
+	}
+
+	page := getList(t, server.URL+"/api/v2/location-area/?offset=5&limit=5")
+	if len(page.Results) != 5 || page.Results[0].Name != "oreburgh-mine-1f" {
+		t.Errorf("unexpected page %+v", page.Results)
+	}
+	if page.Next == nil || *page.Next != server.URL+"/api/v2/location-area/?offset=10&limit=5" {
+		t.Errorf("unexpected next %v", page.Next)
+	}
+	if page.Previous == nil || *page.Previous != server.URL+"/api/v2/location-area/?offset=0&limit=5" {
+		t.Errorf("unexpected previous %v", page.Previous)
+	}
+}
+
+func TestResourceByNameAndID(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, path := range []string{"/api/v2/pokemon/pikachu", "/api/v2/pokemon/25/"} {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != 200 || !strings.Contains(string(body), `"name": "pikachu"`) {
+			t.Errorf("%s: unexpected response %d", path, res.StatusCode)
+		}
+		if strings.Contains(string(body), "https://pokeapi.co") {
+			t.Errorf("%s: URLs were not rewritten to the mock server", path)
+		}
+	}
+
+	res, err := http.Get(server.URL + "/api/v2/pokemon/missingno")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 404 {
+		t.Errorf("expected 404, got %d", res.StatusCode)
+	}
+}
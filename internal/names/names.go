@@ -0,0 +1,204 @@
+// Local index of the names of every Pokemon, location area, move and item,
+// for fuzzy search and "did you mean" suggestions.
+// Each kind is downloaded from its list endpoint the first time it's needed
+// and kept in a file.
+package names
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// Kinds are the list endpoints in the index.
+var Kinds = []string{"pokemon", "location-area", "move", "item"}
+
+// Page size used to download the lists (PokeAPI has ~1300 Pokemon).
+const listPageSize = 1000
+
+type Index struct {
+	path string
+
+	mu    sync.Mutex
+	kinds map[string][]string
+}
+
+// Open loads the index kept in path. A missing or damaged file is an empty index.
+func Open(path string) *Index {
+	ix := &Index{path: path, kinds: map[string][]string{}}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		json.Unmarshal(data, &ix.kinds)
+	}
+	return ix
+}
+
+// Names returns every name of a kind, downloading the list if the index doesn't have it.
+func (ix *Index) Names(kind string) ([]string, error) {
+	ix.mu.Lock()
+	names, ok := ix.kinds[kind]
+	ix.mu.Unlock()
+	if ok {
+		return names, nil
+	}
+
+	names = []string{}
+	for r, err := range pokeapi.Resources(pokeapi.ListURL(kind, 0, listPageSize), nil) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, r.Name)
+	}
+
+	ix.mu.Lock()
+	ix.kinds[kind] = names
+	ix.mu.Unlock()
+	return names, ix.save()
+}
+
+// Forget removes kinds from the index, so they are downloaded again.
+func (ix *Index) Forget(kinds ...string) error {
+	ix.mu.Lock()
+	for _, kind := range kinds {
+		delete(ix.kinds, kind)
+	}
+	ix.mu.Unlock()
+	return ix.save()
+}
+
+func (ix *Index) save() error {
+	ix.mu.Lock()
+	data, err := json.Marshal(ix.kinds)
+	ix.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(ix.path), 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(ix.path+".tmp", data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(ix.path+".tmp", ix.path)
+}
+
+type Match struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// 0 for an exact match, then names containing the query, then by edit distance
+	Score int `json:"score"`
+}
+
+// score says how well name matches query, and false if it doesn't match at all.
+func score(query string, name string) (int, bool) {
+	switch {
+	case name == query:
+		return 0, true
+	case strings.HasPrefix(name, query):
+		return 1, true
+	case strings.Contains(name, query):
+		return 2, true
+	}
+
+	// Compare with the name, and with each of its words (so "coronet" finds "mt-coronet-2f")
+	best := Distance(query, name)
+	for _, word := range strings.Split(name, "-") {
+		best = min(best, Distance(query, word))
+	}
+	if best > maxDistance(query) {
+		return 0, false
+	}
+	return 2 + best, true
+}
+
+// maxDistance is how many typos are forgiven: one every four letters.
+func maxDistance(query string) int {
+	return max(1, len(query)/4)
+}
+
+// Search returns the best matches for query in the given kinds (all of them if none).
+// Kinds that can't be downloaded are skipped, and their errors returned with the matches.
+func (ix *Index) Search(query string, limit int, kinds ...string) ([]Match, error) {
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	query = strings.ToLower(query)
+
+	matches := []Match{}
+	errs := []error{}
+	for _, kind := range kinds {
+		names, err := ix.Names(kind)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", kind, err))
+			continue
+		}
+		for _, name := range names {
+			if s, ok := score(query, name); ok {
+				matches = append(matches, Match{Kind: kind, Name: name, Score: s})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score < matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, errors.Join(errs...)
+}
+
+// Suggest returns up to three names of a kind close to name, for "did you mean".
+func (ix *Index) Suggest(kind string, name string) []string {
+	matches, err := ix.Search(name, 3, kind)
+	if err != nil {
+		return nil
+	}
+
+	suggestions := []string{}
+	for _, m := range matches {
+		if m.Name != name {
+			suggestions = append(suggestions, m.Name)
+		}
+	}
+	return suggestions
+}
+
+// Distance is the Levenshtein distance between a and b: how many letters
+// have to be inserted, deleted or changed to turn one into the other.
+func Distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Only two rows of the matrix are needed
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
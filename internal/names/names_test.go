@@ -0,0 +1,85 @@
+package names
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"pikachu", "pikachu", 0},
+		{"pikchu", "pikachu", 1},
+		{"gyardos", "gyarados", 1},
+		{"kitten", "sitting", 3},
+		{"", "abra", 4},
+		{"flabébé", "flabebe", 2},
+	}
+
+	for _, c := range cases {
+		if actual := Distance(c.a, c.b); actual != c.expected {
+			t.Errorf("%s/%s: expected %d, but got %d", c.a, c.b, c.expected, actual)
+		}
+	}
+}
+
+func newTestIndex(t *testing.T) *Index {
+	ix := Open(filepath.Join(t.TempDir(), "names.json"))
+	ix.kinds["pokemon"] = []string{"pikachu", "raichu", "pichu", "magikarp", "gyarados"}
+	ix.kinds["location-area"] = []string{"mt-coronet-2f", "pastoria-city-area"}
+	ix.kinds["move"] = []string{"thunder-shock", "splash"}
+	ix.kinds["item"] = []string{"poke-ball"}
+	return ix
+}
+
+func TestSearch(t *testing.T) {
+	ix := newTestIndex(t)
+
+	cases := []struct {
+		query    string
+		kinds    []string
+		expected []string
+	}{
+		{query: "pichu", expected: []string{"pichu"}},
+		{query: "pikchu", expected: []string{"pichu", "pikachu"}},
+		{query: "chu", expected: []string{"pichu", "pikachu", "raichu"}},
+		{query: "Coronet", expected: []string{"mt-coronet-2f"}},
+		{query: "splsh", expected: []string{"splash"}},
+		{query: "ball", kinds: []string{"pokemon"}, expected: []string{}},
+	}
+
+	for _, c := range cases {
+		matches, err := ix.Search(c.query, 10, c.kinds...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		actual := []string{}
+		for _, m := range matches {
+			actual = append(actual, m.Name)
+		}
+		if len(actual) != len(c.expected) {
+			t.Errorf("%s: expected %v, but got %v", c.query, c.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.expected[i] {
+				t.Errorf("%s: expected %v, but got %v", c.query, c.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	ix := newTestIndex(t)
+
+	suggestions := ix.Suggest("pokemon", "gyarado")
+	if len(suggestions) != 1 || suggestions[0] != "gyarados" {
+		t.Errorf("Expected gyarados, but got %v", suggestions)
+	}
+	if suggestions := ix.Suggest("pokemon", "mewtwo"); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions, but got %v", suggestions)
+	}
+}
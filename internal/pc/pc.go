@@ -0,0 +1,318 @@
+// Party and PC box storage for caught Pokemon.
+// The trainer carries up to PartySize Pokemon; everything else goes to the PC boxes.
+package pc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+const PartySize = 6
+const BoxCount = 8
+const BoxSize = 30
+
+// DefaultLevel is the level of Pokemon caught before there were levels,
+// or where we don't know at which levels they are found.
+const DefaultLevel = 5
+
+var (
+	ErrNotFound        = errors.New("no pokemon with that name or slot")
+	ErrPartyFull       = errors.New("your party is full")
+	ErrBoxFull         = errors.New("that box is full")
+	ErrStorageFull     = errors.New("your party and all PC boxes are full")
+	ErrLastPartyMember = errors.New("you can't leave your party empty")
+	ErrInvalidBox      = errors.New("invalid box number")
+)
+
+// Pokemon is what we keep of a caught Pokemon.
+// PokemonType is too big to store (moves, sprites...), so we only copy the fields we use.
+type Pokemon struct {
+	Name           string          `json:"name"`
+	Nickname       string          `json:"nickname,omitempty"`
+	Species        string          `json:"species,omitempty"`
+	ID             int             `json:"id"`
+	BaseExperience int             `json:"base_experience"`
+	Level          int             `json:"level,omitempty"`
+	Height         int             `json:"height"`
+	Weight         int             `json:"weight"`
+	Stats          []pokeapi.Stats `json:"stats"`
+	Types          []pokeapi.Types `json:"types"`
+	CaughtAt       time.Time       `json:"caught_at"`
+}
+
+func FromAPI(p pokeapi.PokemonType) Pokemon {
+	return Pokemon{
+		Name:           p.Name,
+		Species:        p.Species.Name,
+		ID:             p.ID,
+		BaseExperience: p.BaseExperience,
+		Height:         p.Height,
+		Weight:         p.Weight,
+		Stats:          p.Stats,
+		Types:          p.Types,
+		CaughtAt:       time.Now(),
+	}
+}
+
+// DisplayName returns the nickname if it has one, otherwise the species name.
+func (p Pokemon) DisplayName() string {
+	if p.Nickname != "" {
+		return p.Nickname
+	}
+	return p.Name
+}
+
+func (p Pokemon) TypeNames() []string {
+	names := []string{}
+	for _, t := range p.Types {
+		names = append(names, t.Type.Name)
+	}
+	return names
+}
+
+// Same tells whether p and o are the same caught Pokemon, not just the same species.
+func (p Pokemon) Same(o Pokemon) bool {
+	return p.Name == o.Name && p.CaughtAt.Equal(o.CaughtAt)
+}
+
+func (p Pokemon) matches(ref string) bool {
+	return strings.EqualFold(p.Name, ref) || (p.Nickname != "" && strings.EqualFold(p.Nickname, ref))
+}
+
+type Storage struct {
+	Party      []Pokemon   `json:"party"`
+	Boxes      [][]Pokemon `json:"boxes"`
+	CurrentBox int         `json:"current_box"`
+}
+
+func NewStorage() *Storage {
+	s := &Storage{}
+	s.Normalize()
+	return s
+}
+
+// Normalize makes sure a Storage loaded from an old or hand-edited save has every box.
+func (s *Storage) Normalize() {
+	if s.Party == nil {
+		s.Party = []Pokemon{}
+	}
+	for len(s.Boxes) < BoxCount {
+		s.Boxes = append(s.Boxes, []Pokemon{})
+	}
+	for i := range s.Boxes {
+		if s.Boxes[i] == nil {
+			s.Boxes[i] = []Pokemon{}
+		}
+	}
+	if s.CurrentBox < 0 || s.CurrentBox >= BoxCount {
+		s.CurrentBox = 0
+	}
+}
+
+// Add stores a newly caught Pokemon: in the party if there is room, otherwise
+// in the first PC box with a free slot. It returns where it was placed (-1 for the party).
+func (s *Storage) Add(p Pokemon) (int, error) {
+	if len(s.Party) < PartySize {
+		s.Party = append(s.Party, p)
+		return -1, nil
+	}
+
+	for i := 0; i < BoxCount; i++ {
+		box := (s.CurrentBox + i) % BoxCount
+		if len(s.Boxes[box]) < BoxSize {
+			s.Boxes[box] = append(s.Boxes[box], p)
+			return box, nil
+		}
+	}
+
+	return 0, ErrStorageFull
+}
+
+// Full tells whether Add would fail: the party and every box are full.
+func (s *Storage) Full() bool {
+	return len(s.All()) >= PartySize+BoxCount*BoxSize
+}
+
+// FindParty returns the index in the party of a Pokemon given its slot (1-6), name or nickname.
+func (s *Storage) FindParty(ref string) (int, bool) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 1 && n <= len(s.Party) {
+			return n - 1, true
+		}
+		return 0, false
+	}
+
+	for i, p := range s.Party {
+		if p.matches(ref) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// PartyIndex returns where p is in the party.
+func (s *Storage) PartyIndex(p Pokemon) (int, bool) {
+	for i := range s.Party {
+		if s.Party[i].Same(p) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FindBox looks for a Pokemon in the PC. A number is a slot in the current box;
+// names are searched in the current box first and then in the rest of boxes.
+func (s *Storage) FindBox(ref string) (int, int, bool) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 1 && n <= len(s.Boxes[s.CurrentBox]) {
+			return s.CurrentBox, n - 1, true
+		}
+		return 0, 0, false
+	}
+
+	for i := 0; i < BoxCount; i++ {
+		box := (s.CurrentBox + i) % BoxCount
+		for slot, p := range s.Boxes[box] {
+			if p.matches(ref) {
+				return box, slot, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Find returns a caught Pokemon wherever it is stored.
+func (s *Storage) Find(ref string) (Pokemon, bool) {
+	if i, ok := s.FindParty(ref); ok {
+		return s.Party[i], true
+	}
+	if box, slot, ok := s.FindBox(ref); ok {
+		return s.Boxes[box][slot], true
+	}
+	return Pokemon{}, false
+}
+
+// All returns every caught Pokemon, party first.
+func (s *Storage) All() []Pokemon {
+	all := append([]Pokemon{}, s.Party...)
+	for _, box := range s.Boxes {
+		all = append(all, box...)
+	}
+	return all
+}
+
+// Deposit moves a party Pokemon to the current box (or the next one with room).
+func (s *Storage) Deposit(ref string) (Pokemon, int, error) {
+	i, ok := s.FindParty(ref)
+	if !ok {
+		return Pokemon{}, 0, ErrNotFound
+	}
+	if len(s.Party) == 1 {
+		return Pokemon{}, 0, ErrLastPartyMember
+	}
+
+	for n := 0; n < BoxCount; n++ {
+		box := (s.CurrentBox + n) % BoxCount
+		if len(s.Boxes[box]) < BoxSize {
+			p := s.Party[i]
+			s.Party = append(s.Party[:i], s.Party[i+1:]...)
+			s.Boxes[box] = append(s.Boxes[box], p)
+			return p, box, nil
+		}
+	}
+
+	return Pokemon{}, 0, ErrStorageFull
+}
+
+// Withdraw moves a Pokemon from the PC to the party.
+func (s *Storage) Withdraw(ref string) (Pokemon, error) {
+	box, slot, ok := s.FindBox(ref)
+	if !ok {
+		return Pokemon{}, ErrNotFound
+	}
+	if len(s.Party) >= PartySize {
+		return Pokemon{}, ErrPartyFull
+	}
+
+	p := s.Boxes[box][slot]
+	s.Boxes[box] = append(s.Boxes[box][:slot], s.Boxes[box][slot+1:]...)
+	s.Party = append(s.Party, p)
+	return p, nil
+}
+
+// Swap exchanges two Pokemon. If both are in the party their slots are swapped;
+// if one is in the party and the other in the PC, they trade places.
+func (s *Storage) Swap(a, b string) error {
+	i, aInParty := s.FindParty(a)
+	j, bInParty := s.FindParty(b)
+
+	switch {
+	case aInParty && bInParty:
+		s.Party[i], s.Party[j] = s.Party[j], s.Party[i]
+		return nil
+	case aInParty:
+		box, slot, ok := s.FindBox(b)
+		if !ok {
+			return ErrNotFound
+		}
+		s.Party[i], s.Boxes[box][slot] = s.Boxes[box][slot], s.Party[i]
+		return nil
+	case bInParty:
+		box, slot, ok := s.FindBox(a)
+		if !ok {
+			return ErrNotFound
+		}
+		s.Party[j], s.Boxes[box][slot] = s.Boxes[box][slot], s.Party[j]
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// Release removes a Pokemon for good, looking in the party first.
+func (s *Storage) Release(ref string) (Pokemon, error) {
+	if i, ok := s.FindParty(ref); ok {
+		if len(s.Party) == 1 {
+			return Pokemon{}, ErrLastPartyMember
+		}
+		p := s.Party[i]
+		s.Party = append(s.Party[:i], s.Party[i+1:]...)
+		return p, nil
+	}
+
+	if box, slot, ok := s.FindBox(ref); ok {
+		p := s.Boxes[box][slot]
+		s.Boxes[box] = append(s.Boxes[box][:slot], s.Boxes[box][slot+1:]...)
+		return p, nil
+	}
+
+	return Pokemon{}, ErrNotFound
+}
+
+// Rename sets the nickname of a caught Pokemon, wherever it is. An empty nickname removes it.
+func (s *Storage) Rename(ref string, nickname string) (Pokemon, error) {
+	if i, ok := s.FindParty(ref); ok {
+		s.Party[i].Nickname = nickname
+		return s.Party[i], nil
+	}
+
+	if box, slot, ok := s.FindBox(ref); ok {
+		s.Boxes[box][slot].Nickname = nickname
+		return s.Boxes[box][slot], nil
+	}
+
+	return Pokemon{}, ErrNotFound
+}
+
+// SetBox changes the current box. n is 1-based, as shown to the user.
+func (s *Storage) SetBox(n int) error {
+	if n < 1 || n > BoxCount {
+		return ErrInvalidBox
+	}
+	s.CurrentBox = n - 1
+	return nil
+}
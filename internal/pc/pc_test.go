@@ -0,0 +1,145 @@
+package pc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func fillParty(s *Storage, n int) {
+	for i := 0; i < n; i++ {
+		s.Add(Pokemon{Name: fmt.Sprintf("pokemon%d", i+1)})
+	}
+}
+
+func TestAddOverflowsToBox(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, PartySize)
+
+	box, err := s.Add(Pokemon{Name: "pikachu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if box != 0 {
+		t.Errorf("expected pikachu in box 0, got %d", box)
+	}
+	if len(s.Party) != PartySize {
+		t.Errorf("expected a full party, got %d", len(s.Party))
+	}
+	if s.Full() {
+		t.Errorf("expected room in the PC")
+	}
+}
+
+func TestDepositWithdraw(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, 2)
+
+	_, box, err := s.Deposit("pokemon1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if box != 0 || len(s.Boxes[0]) != 1 {
+		t.Errorf("expected pokemon1 in box 1")
+	}
+
+	_, _, err = s.Deposit("1")
+	if !errors.Is(err, ErrLastPartyMember) {
+		t.Errorf("expected ErrLastPartyMember, got %v", err)
+	}
+
+	_, err = s.Withdraw("pokemon1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Party) != 2 || len(s.Boxes[0]) != 0 {
+		t.Errorf("expected pokemon1 back in the party")
+	}
+}
+
+func TestWithdrawPartyFull(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, PartySize+1)
+
+	_, err := s.Withdraw("pokemon7")
+	if !errors.Is(err, ErrPartyFull) {
+		t.Errorf("expected ErrPartyFull, got %v", err)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, PartySize+1)
+
+	err := s.Swap("1", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Party[0].Name != "pokemon2" || s.Party[1].Name != "pokemon1" {
+		t.Errorf("party slots were not swapped")
+	}
+
+	err = s.Swap("pokemon7", "pokemon3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Party[2].Name != "pokemon7" || s.Boxes[0][0].Name != "pokemon3" {
+		t.Errorf("party and box pokemon were not swapped")
+	}
+}
+
+func TestRelease(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, 1)
+
+	_, err := s.Release("pokemon1")
+	if !errors.Is(err, ErrLastPartyMember) {
+		t.Errorf("expected ErrLastPartyMember, got %v", err)
+	}
+
+	_, err = s.Release("missingno")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	s := NewStorage()
+	fillParty(s, PartySize+1)
+
+	_, err := s.Rename("pokemon7", "Sir Sparks")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, ok := s.Find("sir sparks")
+	if !ok || p.Name != "pokemon7" || p.DisplayName() != "Sir Sparks" {
+		t.Errorf("expected to find pokemon7 by its nickname, got %+v", p)
+	}
+
+	_, err = s.Rename("Sir Sparks", "")
+	if err != nil || s.Boxes[0][0].Nickname != "" {
+		t.Errorf("expected the nickname to be removed, got %v", err)
+	}
+
+	_, err = s.Rename("missingno", "Glitch")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPartyIndex(t *testing.T) {
+	s := NewStorage()
+	first := Pokemon{Name: "abra", CaughtAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	second := Pokemon{Name: "abra", CaughtAt: first.CaughtAt.Add(time.Hour)}
+	s.Add(first)
+	s.Add(second)
+
+	i, ok := s.PartyIndex(second)
+	if !ok || i != 1 {
+		t.Errorf("expected the second abra in slot 1, got %d %v", i, ok)
+	}
+	if _, ok := s.PartyIndex(Pokemon{Name: "abra"}); ok {
+		t.Errorf("expected an abra caught at another time not to be found")
+	}
+}
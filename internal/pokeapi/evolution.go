@@ -0,0 +1,68 @@
+package pokeapi
+
+import (
+	"github.com/neixir/pokedex/internal/pokecache"
+)
+
+// PokemonSpecies is what the Pokemon of a species share, e.g. pikachu and its forms.
+// Only the fields we use.
+type PokemonSpecies struct {
+	ID                 int               `json:"id"`
+	Name               string            `json:"name"`
+	EvolutionChain     APIResource       `json:"evolution_chain"`
+	EvolvesFromSpecies *NamedAPIResource `json:"evolves_from_species"`
+}
+
+type APIResource struct {
+	URL string `json:"url"`
+}
+
+type EvolutionChain struct {
+	ID    int       `json:"id"`
+	Chain ChainLink `json:"chain"`
+}
+
+// ChainLink is a species of an evolution chain and what it evolves into.
+type ChainLink struct {
+	Species NamedAPIResource `json:"species"`
+	// How this species evolves from the previous one
+	EvolutionDetails []EvolutionDetail `json:"evolution_details"`
+	EvolvesTo        []ChainLink       `json:"evolves_to"`
+}
+
+// EvolutionDetail is one way of evolving. Only the conditions we use.
+type EvolutionDetail struct {
+	Trigger  NamedAPIResource  `json:"trigger"`
+	MinLevel *int              `json:"min_level"`
+	Item     *NamedAPIResource `json:"item"`
+	HeldItem *NamedAPIResource `json:"held_item"`
+	// For trades: the species it has to be traded for (Karrablast and Shelmet)
+	TradeSpecies *NamedAPIResource `json:"trade_species"`
+}
+
+// Find returns the link of species in the chain.
+func (c ChainLink) Find(species string) (ChainLink, bool) {
+	if c.Species.Name == species {
+		return c, true
+	}
+	for _, next := range c.EvolvesTo {
+		if link, ok := next.Find(species); ok {
+			return link, true
+		}
+	}
+	return ChainLink{}, false
+}
+
+// GetSpecies returns a Pokemon species by name or id.
+func GetSpecies(name string, cache *pokecache.Cache) (PokemonSpecies, error) {
+	species := PokemonSpecies{}
+	err := getJSON(SpeciesUrl+name, "species", cache, &species)
+	return species, err
+}
+
+// GetEvolutionChain returns the chain at url, the one of a species.
+func GetEvolutionChain(url string, cache *pokecache.Cache) (EvolutionChain, error) {
+	chain := EvolutionChain{}
+	err := getJSON(url, "evolution chain", cache, &chain)
+	return chain, err
+}
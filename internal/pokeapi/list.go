@@ -0,0 +1,87 @@
+package pokeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+
+	"github.com/neixir/pokedex/internal/pokecache"
+)
+
+// ListURL returns the URL of a page of a list endpoint, e.g. ListURL("pokemon", 0, 100).
+func ListURL(resource string, offset int, limit int) string {
+	return fmt.Sprintf("%s%s/?offset=%d&limit=%d", BaseURL, resource, offset, limit)
+}
+
+// GetList returns one page of a list endpoint. The cache can be nil.
+func GetList(url string, cache *pokecache.Cache) (NamedAPIResourceList, error) {
+	list := NamedAPIResourceList{}
+
+	// Si es al cache ho retornem
+	var body []byte
+	ok := false
+	if cache != nil {
+		body, ok = cache.Get(url)
+	}
+	if ok {
+		fmt.Fprintf(DebugOutput, "Obtenint %s del cache.\n", url)
+	} else {
+		var err error
+		body, err = fetch(url, "")
+		if err != nil {
+			return list, err
+		}
+
+		if cache != nil {
+			cache.Add(url, body)
+			fmt.Fprintf(DebugOutput, "Afegint %s al cache.\n", url)
+		}
+	}
+
+	// https://blog.boot.dev/golang/json-golang/#example-unmarshal-json-to-struct-decode
+	err := json.Unmarshal(body, &list)
+	if err != nil {
+		return list, err
+	}
+
+	return list, nil
+}
+
+// Pages walks a list endpoint from url following the Next links. A page is
+// only fetched when the loop asks for it. On error it yields the error and stops.
+//
+//	for page, err := range pokeapi.Pages(pokeapi.ListURL("move", 0, 100), nil) {
+func Pages(url string, cache *pokecache.Cache) iter.Seq2[NamedAPIResourceList, error] {
+	return func(yield func(NamedAPIResourceList, error) bool) {
+		// Every range starts from the first page
+		next := url
+		for next != "" {
+			page, err := GetList(next, cache)
+			if err != nil {
+				yield(page, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+			next = page.Next
+		}
+	}
+}
+
+// Resources walks every item of a list endpoint, page by page (see Pages).
+func Resources(url string, cache *pokecache.Cache) iter.Seq2[NamedAPIResource, error] {
+	return func(yield func(NamedAPIResource, error) bool) {
+		for page, err := range Pages(url, cache) {
+			if err != nil {
+				yield(NamedAPIResource{}, err)
+				return
+			}
+			for _, resource := range page.Results {
+				if !yield(resource, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -4,98 +4,159 @@ package pokeapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/neixir/pokedex/internal/pokecache"
 )
 
-const LocationAreaUrl = "https://pokeapi.co/api/v2/location-area/"
-const PokemonUrl = "https://pokeapi.co/api/v2/pokemon/"
+// Client does every request to PokeAPI. Its Transport can be replaced,
+// e.g. with a cassette to work offline.
+var Client = &http.Client{}
 
-func GetLocationArea(url string, cache *pokecache.Cache) (LocationArea, error) {
-	area := LocationArea{}
+// DebugOutput is where the cache messages go. Set it to io.Discard to silence them.
+var DebugOutput io.Writer = os.Stdout
 
-	// Si es al cache ho retornem
-	body, ok := cache.Get(url)
-	if ok {
-		fmt.Printf("Obtenint %s del cache.\n", url)
-	} else {
-		// https://pkg.go.dev/net/http#example-Get
-		res, err := http.Get(url)
-		if err != nil {
-			return area, fmt.Errorf("could not connect to PokeAPI")
-		}
+const DefaultBaseURL = "https://pokeapi.co/api/v2/"
 
-		body, err = io.ReadAll(res.Body)
-		if res.StatusCode > 299 {
-			return area, fmt.Errorf("response failed with status code: %d", res.StatusCode)
-		}
-		if err != nil {
-			return area, err
-		}
+// Endpoints. They change with SetBaseURL.
+var (
+	BaseURL         = DefaultBaseURL
+	LocationAreaUrl = DefaultBaseURL + "location-area/"
+	PokemonUrl      = DefaultBaseURL + "pokemon/"
+	PokedexUrl      = DefaultBaseURL + "pokedex/"
+	GenerationUrl   = DefaultBaseURL + "generation/"
+	TypeUrl         = DefaultBaseURL + "type/"
+	SpeciesUrl      = DefaultBaseURL + "pokemon-species/"
+)
 
-		cache.Add(url, body)
-		fmt.Printf("Afegint %s al cache.\n", url)
+// SetBaseURL points the client to another PokeAPI, e.g. http://localhost:8080/api/v2/
+// for "pokedex serve-mock".
+func SetBaseURL(base string) {
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	BaseURL = base
+	LocationAreaUrl = base + "location-area/"
+	PokemonUrl = base + "pokemon/"
+	PokedexUrl = base + "pokedex/"
+	GenerationUrl = base + "generation/"
+	TypeUrl = base + "type/"
+	SpeciesUrl = base + "pokemon-species/"
+}
+
+// Mirror is an offline copy of PokeAPI (see "sync"). When set, it is asked
+// before going to the network.
+var Mirror interface {
+	Get(url string) ([]byte, bool)
+}
+
+// ErrNotFound is matched (with errors.Is) by the errors of resources that don't exist.
+var ErrNotFound = errors.New("not found")
 
-		defer res.Body.Close()
+// NotFoundError is returned when PokeAPI answers 404.
+type NotFoundError struct {
+	URL string
+	// What was asked for, e.g. "pokemon". Can be empty.
+	What string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.What == "" {
+		return fmt.Sprintf("response failed with status code: %d", http.StatusNotFound)
 	}
+	return fmt.Sprintf("response failed with status code: %d (probably no %s with that name)", http.StatusNotFound, e.What)
+}
 
-	// https://blog.boot.dev/golang/json-golang/#example-unmarshal-json-to-struct-decode
-	err := json.Unmarshal(body, &area)
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// fetch returns the body of url, from the mirror if it has it.
+// what is used in the 404 message, e.g. "probably no pokemon with that name".
+func fetch(url string, what string) ([]byte, error) {
+	if Mirror != nil {
+		if body, ok := Mirror.Get(url); ok {
+			return body, nil
+		}
+	}
+	return Download(url, what)
+}
+
+// Download gets url from PokeAPI, skipping the mirror.
+func Download(url string, what string) ([]byte, error) {
+	// https://pkg.go.dev/net/http#example-Get
+	res, err := Client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to PokeAPI")
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return nil, &NotFoundError{URL: url, What: what}
+	}
+	if res.StatusCode > 299 {
+		return nil, fmt.Errorf("response failed with status code: %d", res.StatusCode)
+	}
 	if err != nil {
-		return area, err
+		return nil, err
 	}
 
-	return area, nil
+	return body, nil
+}
+
+func GetLocationArea(url string, cache *pokecache.Cache) (LocationArea, error) {
+	return GetList(url, cache)
 }
 
 func GetPokemonNamesByArea(areaName string, cache *pokecache.Cache) ([]string, error) {
 	names := []string{}
 
+	areaInfo, err := GetLocationAreaInfo(areaName, cache)
+	if err != nil {
+		return names, err
+	}
+
+	for i := range areaInfo.PokemonEncounters {
+		names = append(names, areaInfo.PokemonEncounters[i].Pokemon.Name)
+	}
+
+	return names, nil
+}
+
+func GetLocationAreaInfo(areaName string, cache *pokecache.Cache) (LocationAreaInfo, error) {
+	areaInfo := LocationAreaInfo{}
+
 	url := fmt.Sprintf("%s%s", LocationAreaUrl, areaName)
 
 	// Si es al cache ho retornem
-	body, ok := cache.Get(areaName)
+	body, ok := cache.Get(url)
 	if ok {
-		fmt.Printf("Obtenint %s del cache.\n", areaName)
+		fmt.Fprintf(DebugOutput, "Obtenint %s del cache.\n", areaName)
 	} else {
-		// https://pkg.go.dev/net/http#example-Get
-		res, err := http.Get(url)
+		var err error
+		body, err = fetch(url, "area")
 		if err != nil {
-			return names, fmt.Errorf("could not connect to PokeAPI")
-		}
-
-		body, err = io.ReadAll(res.Body)
-		if res.StatusCode > 299 {
-			if res.StatusCode == 404 {
-				return names, fmt.Errorf("response failed with status code: %d (probably no area with that name)", res.StatusCode)
-			}
-			return names, fmt.Errorf("response failed with status code: %d", res.StatusCode)
-		}
-		if err != nil {
-			return names, err
+			return areaInfo, err
 		}
 
 		cache.Add(url, body)
-		fmt.Printf("Afegint %s al cache.\n", areaName)
-
-		defer res.Body.Close()
+		fmt.Fprintf(DebugOutput, "Afegint %s al cache.\n", areaName)
 	}
 
 	// https://blog.boot.dev/golang/json-golang/#example-unmarshal-json-to-struct-decode
-	areaInfo := LocationAreaInfo{}
 	err := json.Unmarshal(body, &areaInfo)
 	if err != nil {
-		return names, err
-	}
-
-	for i, _ := range areaInfo.PokemonEncounters {
-		names = append(names, areaInfo.PokemonEncounters[i].Pokemon.Name)
+		return areaInfo, err
 	}
 
-	return names, nil
+	return areaInfo, nil
 }
 
 // TODO Utilitzar cache
@@ -103,24 +164,11 @@ func GetPokemon(name string) (PokemonType, error) {
 	pokemon := PokemonType{}
 	url := PokemonUrl + name
 
-	res, err := http.Get(url)
-	if err != nil {
-		return pokemon, fmt.Errorf("could not connect to PokeAPI")
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if res.StatusCode > 299 {
-		if res.StatusCode == 404 {
-			return pokemon, fmt.Errorf("response failed with status code: %d (probably no pokemon with that name)", res.StatusCode)
-		}
-		return pokemon, fmt.Errorf("response failed with status code: %d", res.StatusCode)
-	}
+	body, err := fetch(url, "pokemon")
 	if err != nil {
 		return pokemon, err
 	}
 
-	defer res.Body.Close()
-
 	err = json.Unmarshal(body, &pokemon)
 	if err != nil {
 		return pokemon, err
@@ -128,3 +176,52 @@ func GetPokemon(name string) (PokemonType, error) {
 
 	return pokemon, nil
 }
+
+// getJSON fetches url (from the cache if it's there) and decodes it into v.
+// what is used in the 404 message, e.g. "probably no type with that name".
+func getJSON(url string, what string, cache *pokecache.Cache, v any) error {
+	body, ok := cache.Get(url)
+	if !ok {
+		var err error
+		body, err = fetch(url, what)
+		if err != nil {
+			return err
+		}
+
+		cache.Add(url, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// GetPokedex returns a regional Pokedex (kanto, original-johto, hoenn...).
+func GetPokedex(name string, cache *pokecache.Cache) (PokedexInfo, error) {
+	pokedex := PokedexInfo{}
+	err := getJSON(PokedexUrl+name, "pokedex", cache, &pokedex)
+	return pokedex, err
+}
+
+// GetGeneration returns a generation (generation-i, or just 1) with all its species.
+func GetGeneration(name string, cache *pokecache.Cache) (GenerationInfo, error) {
+	generation := GenerationInfo{}
+	err := getJSON(GenerationUrl+name, "generation", cache, &generation)
+	return generation, err
+}
+
+// GetType returns a type with its damage relations and the Pokemon that have it.
+func GetType(name string, cache *pokecache.Cache) (TypeInfo, error) {
+	typeInfo := TypeInfo{}
+	err := getJSON(TypeUrl+name, "type", cache, &typeInfo)
+	return typeInfo, err
+}
+
+// IDFromURL extracts the numeric id at the end of a resource URL,
+// e.g. https://pokeapi.co/api/v2/pokemon/25/ -> 25. It returns 0 if there is none.
+func IDFromURL(url string) int {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	id, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -1,15 +1,17 @@
 package pokeapi
 
-type LocationArea struct {
-	Count    int    `json:"count"`
-	Next     string `json:"next"`
-	Previous string `json:"previous"`
-	Results  []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"results"`
+// NamedAPIResourceList is a page of any list endpoint (location-area, pokemon, move...).
+// Next and Previous are empty on the last and first pages.
+type NamedAPIResourceList struct {
+	Count    int                `json:"count"`
+	Next     string             `json:"next"`
+	Previous string             `json:"previous"`
+	Results  []NamedAPIResource `json:"results"`
 }
 
+// LocationArea is a page of the location-area list.
+type LocationArea = NamedAPIResourceList
+
 type LocationAreaInfo struct {
 	EncounterMethodRates []EncounterMethodRates `json:"encounter_method_rates"`
 	GameIndex            int                    `json:"game_index"`
@@ -77,6 +79,21 @@ type PokemonEncounters struct {
 	VersionDetails []VersionDetails `json:"version_details"`
 }
 
+// Levels returns the lowest and highest level the Pokemon is found at, in any game.
+// Both are 0 if there are no details.
+func (e PokemonEncounters) Levels() (int, int) {
+	low, high := 0, 0
+	for _, version := range e.VersionDetails {
+		for _, d := range version.EncounterDetails {
+			if low == 0 || d.MinLevel < low {
+				low = d.MinLevel
+			}
+			high = max(high, d.MaxLevel)
+		}
+	}
+	return low, high
+}
+
 // *********
 type PokemonType struct {
 	Abilities              []Abilities     `json:"abilities"`
@@ -393,3 +410,47 @@ type Types struct {
 	Slot int  `json:"slot"`
 	Type Type `json:"type"`
 }
+
+// *********
+type NamedAPIResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type PokedexInfo struct {
+	ID             int              `json:"id"`
+	Name           string           `json:"name"`
+	IsMainSeries   bool             `json:"is_main_series"`
+	Region         NamedAPIResource `json:"region"`
+	PokemonEntries []struct {
+		EntryNumber    int              `json:"entry_number"`
+		PokemonSpecies NamedAPIResource `json:"pokemon_species"`
+	} `json:"pokemon_entries"`
+}
+
+type GenerationInfo struct {
+	ID             int                `json:"id"`
+	Name           string             `json:"name"`
+	MainRegion     NamedAPIResource   `json:"main_region"`
+	PokemonSpecies []NamedAPIResource `json:"pokemon_species"`
+}
+
+type DamageRelations struct {
+	DoubleDamageFrom []NamedAPIResource `json:"double_damage_from"`
+	DoubleDamageTo   []NamedAPIResource `json:"double_damage_to"`
+	HalfDamageFrom   []NamedAPIResource `json:"half_damage_from"`
+	HalfDamageTo     []NamedAPIResource `json:"half_damage_to"`
+	NoDamageFrom     []NamedAPIResource `json:"no_damage_from"`
+	NoDamageTo       []NamedAPIResource `json:"no_damage_to"`
+}
+
+type TypeInfo struct {
+	ID              int              `json:"id"`
+	Name            string           `json:"name"`
+	DamageRelations DamageRelations  `json:"damage_relations"`
+	Generation      NamedAPIResource `json:"generation"`
+	Pokemon         []struct {
+		Slot    int              `json:"slot"`
+		Pokemon NamedAPIResource `json:"pokemon"`
+	} `json:"pokemon"`
+}
@@ -0,0 +1,192 @@
+package pokeapi
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neixir/pokedex/internal/cassette"
+	"github.com/neixir/pokedex/internal/mockapi"
+	"github.com/neixir/pokedex/internal/pokecache"
+)
+
+// The tests replay the PokeAPI responses recorded in the repository testdata,
+// so they don't need the network.
+func TestMain(m *testing.M) {
+	Client.Transport = cassette.New("../../testdata/cassettes", cassette.Replay)
+	DebugOutput = &strings.Builder{}
+	os.Exit(m.Run())
+}
+
+func TestGetLocationArea(t *testing.T) {
+	cache := pokecache.NewCache(5 * time.Second)
+
+	area, err := GetLocationArea(ListURL("location-area", 0, 5), cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(area.Results) != 5 || area.Results[0].Name != "canalave-city-area" {
+		t.Errorf("unexpected first page %v", area.Results)
+	}
+	if area.Previous != "" || !strings.Contains(area.Next, "offset=5") {
+		t.Errorf("unexpected next %q and previous %q", area.Next, area.Previous)
+	}
+}
+
+func TestGetLocationAreaInfo(t *testing.T) {
+	cache := pokecache.NewCache(5 * time.Second)
+
+	names, err := GetPokemonNamesByArea("pastoria-city-area", cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 10 || names[0] != "tentacool" {
+		t.Errorf("unexpected pokemon %v", names)
+	}
+
+	_, err = GetLocationAreaInfo("nowhere", cache)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestGetPokemon(t *testing.T) {
+	pokemon, err := GetPokemon("pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.ID != 25 || pokemon.BaseExperience != 112 || pokemon.Types[0].Type.Name != "electric" {
+		t.Errorf("unexpected pokemon %+v", pokemon)
+	}
+
+	_, err = GetPokemon("missingno")
+	if err == nil || !strings.Contains(err.Error(), "no pokemon with that name") {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v to be ErrNotFound", err)
+	}
+}
+
+func TestIDFromURL(t *testing.T) {
+	cases := []struct {
+		url      string
+		expected int
+	}{
+		{url: "https://pokeapi.co/api/v2/pokemon/25/", expected: 25},
+		{url: "https://pokeapi.co/api/v2/pokemon/25", expected: 25},
+		{url: "https://pokeapi.co/api/v2/pokemon/pikachu/", expected: 0},
+	}
+
+	for _, c := range cases {
+		if actual := IDFromURL(c.url); actual != c.expected {
+			t.Errorf("%s: expected %d, but got %d", c.url, c.expected, actual)
+		}
+	}
+}
+
+func TestResources(t *testing.T) {
+	server := newMockServer(t)
+	defer server.Close()
+
+	names := []string{}
+	pages := 0
+	for page, err := range Pages(ListURL("location-area", 0, 6), nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pages++
+		for _, r := range page.Results {
+			names = append(names, r.Name)
+		}
+	}
+	if pages != 4 || len(names) != 20 || names[5] != "oreburgh-mine-1f" {
+		t.Errorf("unexpected %d pages with %v", pages, names)
+	}
+
+	// The same sequence can be walked again, from the first page
+	seq := Pages(ListURL("location-area", 0, 6), nil)
+	for range 2 {
+		pages = 0
+		for _, err := range seq {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pages++
+		}
+		if pages != 4 {
+			t.Errorf("expected 4 pages every time, got %d", pages)
+		}
+	}
+
+	// Stopping early doesn't fetch the rest
+	count := 0
+	for _, err := range Resources(ListURL("pokemon", 0, 5), nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		count++
+		if count == 7 {
+			break
+		}
+	}
+	if count != 7 {
+		t.Errorf("expected 7 pokemon, got %d", count)
+	}
+
+	for _, err := range Resources(ListURL("nothing", 0, 5), nil) {
+		if err == nil {
+			t.Errorf("expected an error listing an unknown resource")
+		}
+	}
+}
+
+func TestEvolutionChain(t *testing.T) {
+	server := newMockServer(t)
+	defer server.Close()
+	cache := pokecache.NewCache(time.Minute)
+
+	species, err := GetSpecies("kadabra", cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if species.EvolvesFromSpecies == nil || species.EvolvesFromSpecies.Name != "abra" {
+		t.Errorf("expected kadabra to evolve from abra, got %v", species.EvolvesFromSpecies)
+	}
+
+	chain, err := GetEvolutionChain(species.EvolutionChain.URL, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	link, ok := chain.Chain.Find("kadabra")
+	if !ok || len(link.EvolvesTo) != 1 {
+		t.Fatalf("expected kadabra in chain %d", chain.ID)
+	}
+	next := link.EvolvesTo[0]
+	if next.Species.Name != "alakazam" || next.EvolutionDetails[0].Trigger.Name != "trade" {
+		t.Errorf("expected kadabra to evolve into alakazam by trade, got %s by %v", next.Species.Name, next.EvolutionDetails)
+	}
+	if _, ok := chain.Chain.Find("pikachu"); ok {
+		t.Errorf("pikachu is not in the chain of abra")
+	}
+}
+
+// newMockServer points the client to the bundled fixtures of serve-mock until the server is closed.
+func newMockServer(t *testing.T) *httptest.Server {
+	s, err := mockapi.New(mockapi.Fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := Client.Transport
+	Client.Transport = nil
+	server := httptest.NewServer(s)
+	SetBaseURL(server.URL + "/api/v2/")
+	t.Cleanup(func() {
+		Client.Transport = transport
+		SetBaseURL(DefaultBaseURL)
+	})
+	return server
+}
@@ -0,0 +1,62 @@
+package pokeapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Generations with sprites, for SpriteURL.
+var SpriteGenerations = []string{"i", "ii", "iii", "iv", "v", "vi", "vii"}
+
+// SpriteURL returns the URL of a sprite of the Pokemon. generation is a roman
+// number ("i" is Red/Blue, "iii" Emerald...), or "" for the current sprites.
+// Not every generation has back or shiny sprites.
+func SpriteURL(p PokemonType, generation string, back bool, shiny bool) (string, error) {
+	s := p.Sprites
+	v := s.Versions
+
+	// front, back, front shiny, back shiny
+	var urls [4]string
+	switch strings.ToLower(strings.TrimPrefix(generation, "generation-")) {
+	case "":
+		urls = [4]string{s.FrontDefault, s.BackDefault, s.FrontShiny, s.BackShiny}
+	case "i", "1":
+		urls = [4]string{v.GenerationI.RedBlue.FrontDefault, v.GenerationI.RedBlue.BackDefault}
+	case "ii", "2":
+		c := v.GenerationIi.Crystal
+		urls = [4]string{c.FrontDefault, c.BackDefault, c.FrontShiny, c.BackShiny}
+	case "iii", "3":
+		// Emerald only has front sprites, FireRed/LeafGreen has the backs
+		e, f := v.GenerationIii.Emerald, v.GenerationIii.FireredLeafgreen
+		urls = [4]string{e.FrontDefault, f.BackDefault, e.FrontShiny, f.BackShiny}
+	case "iv", "4":
+		pl := v.GenerationIv.Platinum
+		urls = [4]string{pl.FrontDefault, pl.BackDefault, pl.FrontShiny, pl.BackShiny}
+	case "v", "5":
+		bw := v.GenerationV.BlackWhite
+		urls = [4]string{bw.FrontDefault, bw.BackDefault, bw.FrontShiny, bw.BackShiny}
+	case "vi", "6":
+		xy := v.GenerationVi.XY
+		urls = [4]string{xy.FrontDefault, "", xy.FrontShiny}
+	case "vii", "7":
+		usum := v.GenerationVii.UltraSunUltraMoon
+		urls = [4]string{usum.FrontDefault, "", usum.FrontShiny}
+	default:
+		return "", fmt.Errorf("unknown generation %q, use one of %s", generation, strings.Join(SpriteGenerations, ", "))
+	}
+
+	i, kind := 0, "front"
+	if back {
+		i, kind = 1, "back"
+	}
+	if shiny {
+		i, kind = i+2, "shiny "+kind
+	}
+	if urls[i] == "" {
+		if generation != "" {
+			return "", fmt.Errorf("%s has no %s sprite in generation %s", p.Name, kind, generation)
+		}
+		return "", fmt.Errorf("%s has no %s sprite", p.Name, kind)
+	}
+	return urls[i], nil
+}
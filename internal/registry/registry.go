@@ -0,0 +1,196 @@
+// Command registry for the REPL.
+// Commands declare their aliases, how many arguments they take and their help text,
+// and the registry takes care of looking them up and validating the arguments.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownCommand = errors.New("Unknown command")
+
+// Command is generic over the value passed to the callback (the REPL uses *Config).
+type Command[C any] struct {
+	Name     string
+	Aliases  []string
+	Category string
+	// Usage is the synopsis shown by help, e.g. "explore <area name>".
+	Usage       string
+	Description string
+	// Help is the long text shown by "help <command>".
+	Help string
+	// Number of positional arguments. Options (--name=value) don't count.
+	// MaxArgs < 0 means there is no maximum.
+	MinArgs int
+	MaxArgs int
+	// Callback returns the result to show to the user (nil for nothing).
+	Callback func(C) (any, error)
+}
+
+// UsageError is returned when a command gets the wrong number of arguments.
+type UsageError struct {
+	Command string
+	Usage   string
+	Reason  string
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("%s\nusage: %s", e.Reason, e.Usage)
+}
+
+type Group[C any] struct {
+	Category string
+	Commands []*Command[C]
+}
+
+type Registry[C any] struct {
+	commands   map[string]*Command[C]
+	aliases    map[string]string
+	categories []string
+}
+
+func New[C any]() *Registry[C] {
+	return &Registry[C]{
+		commands: map[string]*Command[C]{},
+		aliases:  map[string]string{},
+	}
+}
+
+// Register adds a command. Names and aliases must be unique.
+func (r *Registry[C]) Register(cmd Command[C]) error {
+	if cmd.Name == "" {
+		return fmt.Errorf("command without name")
+	}
+	if cmd.Callback == nil {
+		return fmt.Errorf("command %s has no callback", cmd.Name)
+	}
+	if cmd.Usage == "" {
+		cmd.Usage = cmd.Name
+	}
+	if cmd.Category == "" {
+		cmd.Category = "Other"
+	}
+
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, name := range names {
+		if _, ok := r.Lookup(name); ok {
+			return fmt.Errorf("command %s is already registered", name)
+		}
+	}
+
+	r.commands[cmd.Name] = &cmd
+	for _, alias := range cmd.Aliases {
+		r.aliases[alias] = cmd.Name
+	}
+
+	found := false
+	for _, category := range r.categories {
+		if category == cmd.Category {
+			found = true
+		}
+	}
+	if !found {
+		r.categories = append(r.categories, cmd.Category)
+	}
+
+	return nil
+}
+
+// MustRegister is Register for the built-in commands, where a duplicate is a programming error.
+func (r *Registry[C]) MustRegister(cmds ...Command[C]) {
+	for _, cmd := range cmds {
+		err := r.Register(cmd)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Lookup finds a command by name or alias.
+func (r *Registry[C]) Lookup(name string) (*Command[C], bool) {
+	name = strings.ToLower(name)
+	if cmd, ok := r.commands[name]; ok {
+		return cmd, true
+	}
+	if target, ok := r.aliases[name]; ok {
+		return r.commands[target], true
+	}
+	return nil, false
+}
+
+// Commands returns all the commands sorted by name.
+func (r *Registry[C]) Commands() []*Command[C] {
+	cmds := []*Command[C]{}
+	for _, cmd := range r.commands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name < cmds[j].Name
+	})
+	return cmds
+}
+
+// Groups returns the commands grouped by category, in the order categories were first registered.
+func (r *Registry[C]) Groups() []Group[C] {
+	groups := []Group[C]{}
+	for _, category := range r.categories {
+		group := Group[C]{Category: category}
+		for _, cmd := range r.Commands() {
+			if cmd.Category == category {
+				group.Commands = append(group.Commands, cmd)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// Names returns the name of every command, sorted. Aliases are left out.
+func (r *Registry[C]) Names() []string {
+	names := []string{}
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckArgs validates the arguments (without the command name) against the command spec.
+func (cmd *Command[C]) CheckArgs(args []string) error {
+	n := 0
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			n++
+		}
+	}
+
+	if n < cmd.MinArgs {
+		return &UsageError{Command: cmd.Name, Usage: cmd.Usage, Reason: "missing arguments"}
+	}
+	if cmd.MaxArgs >= 0 && n > cmd.MaxArgs {
+		return &UsageError{Command: cmd.Name, Usage: cmd.Usage, Reason: "too many arguments"}
+	}
+	return nil
+}
+
+// Run looks up argv[0], checks the arguments and calls the command.
+func (r *Registry[C]) Run(c C, argv []string) (any, error) {
+	if len(argv) == 0 {
+		return nil, nil
+	}
+
+	cmd, ok := r.Lookup(argv[0])
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+
+	err := cmd.CheckArgs(argv[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd.Callback(c)
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestRegistry(calls *[]string) *Registry[*[]string] {
+	r := New[*[]string]()
+	r.MustRegister(
+		Command[*[]string]{
+			Name:     "explore",
+			Aliases:  []string{"ex"},
+			Category: "Exploration",
+			Usage:    "explore <area name>",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Callback: func(c *[]string) (any, error) {
+				*c = append(*c, "explore")
+				return nil, nil
+			},
+		},
+		Command[*[]string]{
+			Name:     "help",
+			Category: "General",
+			MaxArgs:  -1,
+			Callback: func(c *[]string) (any, error) {
+				*c = append(*c, "help")
+				return nil, nil
+			},
+		},
+	)
+	return r
+}
+
+func TestRunResolvesAliases(t *testing.T) {
+	calls := []string{}
+	r := newTestRegistry(&calls)
+
+	_, err := r.Run(&calls, []string{"ex", "pastoria-city-area"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "explore" {
+		t.Errorf("expected explore to be called, got %v", calls)
+	}
+}
+
+func TestRunValidatesArgs(t *testing.T) {
+	calls := []string{}
+	r := newTestRegistry(&calls)
+
+	cases := []struct {
+		argv []string
+		err  error
+	}{
+		{argv: []string{"explore"}, err: &UsageError{}},
+		{argv: []string{"explore", "a", "b"}, err: &UsageError{}},
+		{argv: []string{"explore", "a", "--verbose"}, err: nil},
+		{argv: []string{"catch", "pikachu"}, err: ErrUnknownCommand},
+	}
+
+	for _, c := range cases {
+		_, err := r.Run(&calls, c.argv)
+		var usage *UsageError
+		switch {
+		case c.err == nil && err != nil:
+			t.Errorf("%v: unexpected error %v", c.argv, err)
+		case c.err == ErrUnknownCommand && !errors.Is(err, ErrUnknownCommand):
+			t.Errorf("%v: expected ErrUnknownCommand, got %v", c.argv, err)
+		case c.err != nil && c.err != ErrUnknownCommand && !errors.As(err, &usage):
+			t.Errorf("%v: expected a UsageError, got %v", c.argv, err)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	calls := []string{}
+	r := newTestRegistry(&calls)
+
+	err := r.Register(Command[*[]string]{
+		Name:     "ex",
+		Callback: func(c *[]string) (any, error) { return nil, nil },
+	})
+	if err == nil {
+		t.Errorf("expected an error registering a name used as alias")
+	}
+}
+
+func TestGroups(t *testing.T) {
+	calls := []string{}
+	r := newTestRegistry(&calls)
+
+	groups := r.Groups()
+	if len(groups) != 2 || groups[0].Category != "Exploration" || groups[1].Category != "General" {
+		t.Errorf("unexpected groups %v", groups)
+	}
+}
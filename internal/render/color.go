@@ -0,0 +1,29 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Colors reports whether w is a terminal that can be written colours to.
+// Setting NO_COLOR turns them off (https://no-color.org).
+func Colors(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Foreground returns s in a truecolor foreground colour.
+func Foreground(s string, r, g, b uint8) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, s)
+}
+
+// Badge returns s in black over a truecolor background.
+func Badge(s string, r, g, b uint8) string {
+	return fmt.Sprintf("\x1b[30;48;2;%d;%d;%dm %s \x1b[0m", r, g, b, s)
+}
@@ -0,0 +1,107 @@
+// Rendering of command results in the output format chosen by the user
+// (--output=text|json|yaml|table).
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type Format string
+
+const (
+	Text  Format = "text"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	Table Format = "table"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case Text, JSON, YAML, Table:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (use text, json, yaml or table)", s)
+}
+
+// Texter is implemented by results that know how to print themselves for humans.
+type Texter interface {
+	Text(w io.Writer)
+}
+
+// Tabler is implemented by results that can be shown as a table.
+type Tabler interface {
+	Table() (header []string, rows [][]string)
+}
+
+// Message is the result of commands that only have something to say.
+type Message struct {
+	Message string `json:"message"`
+}
+
+func Messagef(format string, a ...any) Message {
+	return Message{Message: fmt.Sprintf(format, a...)}
+}
+
+func (m Message) Text(w io.Writer) {
+	fmt.Fprintln(w, m.Message)
+}
+
+func (m Message) Table() ([]string, [][]string) {
+	return []string{"message"}, [][]string{{m.Message}}
+}
+
+// Render writes v to w in the given format. A nil v writes nothing.
+func Render(w io.Writer, format Format, v any) error {
+	if v == nil {
+		return nil
+	}
+
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		// "<pokemon>" reads better than "\u003cpokemon\u003e"
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+
+	case YAML:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return writeYAML(w, data)
+
+	case Table:
+		if t, ok := v.(Tabler); ok {
+			header, rows := t.Table()
+			writeTable(w, header, rows)
+			return nil
+		}
+	}
+
+	if t, ok := v.(Texter); ok {
+		t.Text(w)
+		return nil
+	}
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	upper := []string{}
+	for _, h := range header {
+		upper = append(upper, strings.ToUpper(h))
+	}
+	fmt.Fprintln(tw, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	tw.Flush()
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testStat struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+type testResult struct {
+	Name    string     `json:"name"`
+	Height  int        `json:"height"`
+	Stats   []testStat `json:"stats"`
+	Types   []string   `json:"types"`
+	Note    string     `json:"note"`
+	Missing []string   `json:"missing"`
+}
+
+func (r testResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, s := range r.Stats {
+		rows = append(rows, []string{s.Name, "35"})
+	}
+	return []string{"stat", "value"}, rows
+}
+
+func TestRenderYAML(t *testing.T) {
+	result := testResult{
+		Name:    "pikachu",
+		Height:  4,
+		Stats:   []testStat{{Name: "hp", Value: 35}, {Name: "special-attack", Value: 50}},
+		Types:   []string{"electric"},
+		Note:    "yes",
+		Missing: []string{},
+	}
+
+	expected := `name: pikachu
+height: 4
+stats:
+  - name: hp
+    value: 35
+  - name: special-attack
+    value: 50
+types:
+  - electric
+note: "yes"
+missing: []
+`
+
+	var b bytes.Buffer
+	err := Render(&b, YAML, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, b.String())
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	result := testResult{Stats: []testStat{{Name: "hp"}, {Name: "special-attack"}}}
+
+	expected := "STAT            VALUE\nhp              35\nspecial-attack  35\n"
+
+	var b bytes.Buffer
+	Render(&b, Table, result)
+	if b.String() != expected {
+		t.Errorf("Expected:\n%q\nbut got:\n%q", expected, b.String())
+	}
+}
+
+func TestRenderTextFallback(t *testing.T) {
+	var b bytes.Buffer
+
+	// A plain string is neither a Tabler nor a Texter
+	Render(&b, Table, "hello")
+	if b.String() != "hello\n" {
+		t.Errorf("Expected %q, but got %q", "hello\n", b.String())
+	}
+
+	b.Reset()
+	Render(&b, JSON, Messagef("%s was caught!", "pikachu"))
+	if b.String() != "{\n  \"message\": \"pikachu was caught!\"\n}\n" {
+		t.Errorf("unexpected JSON %q", b.String())
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// There is no YAML in the standard library. Results are marshalled to JSON first
+// (so the json tags are respected) and then written out as block-style YAML,
+// keeping the order of the fields.
+
+type yamlNode struct {
+	object bool
+	array  bool
+	keys   []string
+	values []*yamlNode
+	// Already formatted scalar
+	scalar string
+}
+
+func (n *yamlNode) empty() bool {
+	return (n.object || n.array) && len(n.values) == 0
+}
+
+func writeYAML(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root, err := parseNode(dec)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if root.object || root.array {
+		writeNode(&b, root, 0)
+	} else {
+		b.WriteString(root.scalar + "\n")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func parseNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &yamlNode{object: t == '{', array: t == '['}
+		for dec.More() {
+			if node.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, yamlString(key.(string)))
+			}
+			value, err := parseNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, value)
+		}
+		// closing delimiter
+		_, err := dec.Token()
+		return node, err
+	case string:
+		return &yamlNode{scalar: yamlString(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+func writeNode(b *strings.Builder, n *yamlNode, indent int) {
+	pad := strings.Repeat(" ", indent)
+
+	for i, value := range n.values {
+		prefix := pad + "- "
+		if n.object {
+			prefix = pad + n.keys[i] + ":"
+		}
+
+		switch {
+		case value.empty() && value.object:
+			b.WriteString(strings.TrimRight(prefix, " ") + " {}\n")
+		case value.empty():
+			b.WriteString(strings.TrimRight(prefix, " ") + " []\n")
+		case value.object || value.array:
+			if n.array && value.object {
+				// "- key: value" with the rest of keys aligned under the first one
+				var inner strings.Builder
+				writeNode(&inner, value, indent+2)
+				b.WriteString(prefix + strings.TrimLeft(inner.String(), " "))
+			} else {
+				b.WriteString(strings.TrimRight(prefix, " ") + "\n")
+				writeNode(b, value, indent+2)
+			}
+		default:
+			if n.object {
+				prefix += " "
+			}
+			b.WriteString(prefix + value.scalar + "\n")
+		}
+	}
+}
+
+// yamlString quotes a string when writing it plain would change its meaning.
+func yamlString(s string) string {
+	if s == "" {
+		return `""`
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` ") ||
+		strings.HasSuffix(s, " ") ||
+		strings.Contains(s, ": ") ||
+		strings.Contains(s, " #") ||
+		strings.ContainsAny(s, "\n\t\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
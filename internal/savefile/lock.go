@@ -0,0 +1,52 @@
+package savefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLocked is returned by Lock when another Pokedex keeps the lock for too long.
+var ErrLocked = errors.New("another Pokedex is changing the saves, try again")
+
+var (
+	// How long Lock waits for the lock
+	lockWait = 10 * time.Second
+	// How old a lock has to be to belong to a Pokedex that crashed holding it
+	staleLock = time.Minute
+)
+
+// Lock takes the lock of the saves and the trades in dir, for changes that
+// read them and then write them: whoever else wants to waits. It returns the
+// function that releases it.
+func Lock(dir string) (func(), error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "pokedex.lock")
+
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintln(f, os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLocked
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
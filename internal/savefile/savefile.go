@@ -0,0 +1,408 @@
+// Save file: everything that has to survive between Pokedex sessions.
+package savefile
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/neixir/pokedex/internal/dex"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/stats"
+)
+
+// Version 2 added the Pokedex (seen/caught).
+// Version 3 added the trainer profile: name, inventory, location and stats.
+// Version 4 added the badges.
+// Version 5 added the achievements.
+// Version 6 moved the stats to the event log.
+const CurrentVersion = 6
+
+// PokeBall is the item thrown by catch, by its PokeAPI name.
+const PokeBall = "poke-ball"
+
+// Poke Balls a new trainer starts with.
+const StartingBalls = 20
+
+type Save struct {
+	Version int          `json:"version"`
+	Trainer string       `json:"trainer,omitempty"`
+	Storage *pc.Storage  `json:"storage"`
+	Pokedex *dex.Pokedex `json:"pokedex"`
+	// Items by their PokeAPI name, e.g. "poke-ball"
+	Inventory map[string]int `json:"inventory"`
+	// The last area explored
+	Location string `json:"location,omitempty"`
+	// Only in saves from before version 6, see seedEventLog
+	Stats  *Stats  `json:"stats,omitempty"`
+	Badges []Badge `json:"badges,omitempty"`
+	// Achievements unlocked, in the order they were
+	Achievements []Achievement `json:"achievements,omitempty"`
+
+	// The file when it was loaded or written last, see Changed
+	onDisk fileState
+}
+
+// fileState tells a version of a file from the next one.
+type fileState struct {
+	exists bool
+	mod    time.Time
+	size   int64
+}
+
+func stateOf(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, mod: info.ModTime(), size: info.Size()}
+}
+
+func (f fileState) same(other fileState) bool {
+	return f.exists == other.exists && f.mod.Equal(other.mod) && f.size == other.size
+}
+
+// ErrChanged is returned when writing a save whose file was changed by
+// somebody else since it was loaded, e.g. by a trade in another Pokedex.
+var ErrChanged = errors.New("the save was changed by another Pokedex since it was loaded")
+
+// Changed tells whether the file at path isn't the one s was loaded from or written to last.
+func (s *Save) Changed(path string) bool {
+	return !stateOf(path).same(s.onDisk)
+}
+
+type Achievement struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+}
+
+// AchievementIDs are the IDs of the achievements unlocked.
+func (s *Save) AchievementIDs() []string {
+	ids := []string{}
+	for _, a := range s.Achievements {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+// Badge is given by a gym leader when the trainer wins.
+type Badge struct {
+	Name   string    `json:"name"`
+	Region string    `json:"region"`
+	Leader string    `json:"leader"`
+	At     time.Time `json:"at"`
+}
+
+// BadgeNames are the names of the badges of the trainer.
+func (s *Save) BadgeNames() []string {
+	names := []string{}
+	for _, b := range s.Badges {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// Stats count what the trainer has done. Since version 6 it's the event log
+// that keeps them, see EventLogPath and stats.Summarize.
+type Stats struct {
+	AreasExplored int `json:"areas_explored"`
+	BallsThrown   int `json:"balls_thrown"`
+	Caught        int `json:"caught"`
+}
+
+func New() *Save {
+	return &Save{
+		Version:   CurrentVersion,
+		Storage:   pc.NewStorage(),
+		Pokedex:   dex.New(),
+		Inventory: map[string]int{PokeBall: StartingBalls},
+	}
+}
+
+// Dir returns the directory where the Pokedex keeps its files.
+// It can be changed with the POKEDEX_HOME environment variable.
+func Dir() string {
+	if dir := os.Getenv("POKEDEX_HOME"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".pokedex"
+	}
+	return filepath.Join(home, ".pokedex")
+}
+
+// DefaultPath is the save file used when none is given.
+func DefaultPath() string {
+	return filepath.Join(Dir(), "save.json")
+}
+
+// ErrNewerVersion is returned by Load for saves of a newer Pokedex: saving them
+// again would lose what this one doesn't know about.
+var ErrNewerVersion = errors.New("the save is from a newer version of the Pokedex")
+
+// Load reads a save file. If it doesn't exist yet we start a new game.
+// A write of WriteAll that was committed but not finished is finished first.
+func Load(path string) (*Save, error) {
+	err := finishWrite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Before reading: if the file changes in between, Changed will say so
+	onDisk := stateOf(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	save := New()
+	// Unmarshal would add the items of the file to the ones of a new game
+	save.Inventory = nil
+	err = json.Unmarshal(data, save)
+	if err != nil {
+		return nil, err
+	}
+	if save.Version > CurrentVersion {
+		return nil, fmt.Errorf("%w (version %d, this one knows up to %d)", ErrNewerVersion, save.Version, CurrentVersion)
+	}
+	if save.Storage == nil {
+		save.Storage = pc.NewStorage()
+	}
+	save.Storage.Normalize()
+	if save.Pokedex == nil {
+		save.Pokedex = dex.New()
+	}
+
+	// Saves from before the Pokedex existed: everything we own has been caught.
+	if save.Version < 2 {
+		for _, p := range save.Storage.All() {
+			save.Pokedex.MarkCaught(p.Name, p.ID, p.Species, p.TypeNames())
+		}
+	}
+	// Saves from before the profiles: a new bag
+	if save.Inventory == nil {
+		save.Inventory = map[string]int{PokeBall: StartingBalls}
+	}
+	// Saves from before the event log: it gets what they counted
+	if save.Stats != nil {
+		err = seedEventLog(EventLogPath(path), *save.Stats)
+		if err != nil {
+			return nil, fmt.Errorf("could not move the stats to the event log: %w", err)
+		}
+		save.Stats = nil
+	}
+	save.Version = CurrentVersion
+	save.onDisk = onDisk
+
+	return save, nil
+}
+
+// seedEventLog adds to the log at path an event for everything counts has
+// that the log hasn't, i.e. what happened before there was a log. The events
+// only say what happened, not when, to which Pokemon or where (the ball was
+// a Poke Ball, there weren't others). Doing it again adds nothing.
+func seedEventLog(path string, counts Stats) error {
+	events, err := stats.Read(path)
+	if err != nil {
+		return err
+	}
+	logged := stats.Summarize(events)
+
+	seed := []stats.Event{}
+	for range counts.AreasExplored - logged.AreasExplored {
+		seed = append(seed, stats.Event{Kind: stats.Explore})
+	}
+	for range counts.Caught - logged.Caught {
+		seed = append(seed, stats.Event{Kind: stats.Catch, Ball: PokeBall})
+	}
+	for range counts.BallsThrown - counts.Caught - logged.Escapes {
+		seed = append(seed, stats.Event{Kind: stats.Escape, Ball: PokeBall})
+	}
+	if len(seed) == 0 {
+		return nil
+	}
+	return stats.Append(path, seed...)
+}
+
+// Write saves to a temporary file and renames it, so a crash never leaves a half-written save.
+// It returns ErrChanged instead if the file isn't the one s was loaded from.
+// Callers reading and then writing saves hold the Lock.
+func (s *Save) Write(path string) error {
+	if s.Changed(path) {
+		return ErrChanged
+	}
+	return s.write(path)
+}
+
+// write is Write without checking the file first.
+func (s *Save) write(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	err = rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	s.onDisk = stateOf(path)
+	return nil
+}
+
+// Replaced by the tests to make a rename fail
+var rename = os.Rename
+
+// ErrUnfinished is returned by WriteAll when the write was committed but some
+// saves couldn't be put in place yet. Load will finish it.
+var ErrUnfinished = errors.New("the saves were written, but not all of them are in place yet")
+
+// pending is a save waiting next to its file, in <path>.pending, for a write
+// of WriteAll to be committed.
+type pending struct {
+	// The commit file: the write is committed once it exists
+	Commit string `json:"commit"`
+	Save   *Save  `json:"save"`
+}
+
+// WriteAll writes several saves at once, e.g. the two trainers of a trade:
+// either all of them are written or none is.
+//
+// Every save is written next to its file first, and the write is committed
+// by creating a commit file listing them. Then each save is put in place.
+// If that stops halfway, e.g. the program crashes, Load finishes the write
+// of the saves still pending.
+//
+// Like Write, it returns ErrChanged without writing anything if a file isn't
+// the one its save was loaded from.
+func WriteAll(saves map[string]*Save) error {
+	paths := slices.Sorted(maps.Keys(saves))
+	if len(paths) == 1 {
+		return saves[paths[0]].Write(paths[0])
+	}
+	for _, path := range paths {
+		if saves[path].Changed(path) {
+			return fmt.Errorf("%w: %s", ErrChanged, path)
+		}
+	}
+
+	commit := filepath.Join(filepath.Dir(paths[0]), ".write-"+rand.Text()+".commit")
+	written := []string{}
+	abort := func(err error) error {
+		for _, path := range written {
+			os.Remove(path)
+		}
+		return err
+	}
+
+	for _, path := range paths {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return abort(err)
+		}
+		data, err := json.MarshalIndent(pending{Commit: commit, Save: saves[path]}, "", "  ")
+		if err != nil {
+			return abort(err)
+		}
+		err = os.WriteFile(path+".pending", data, 0o644)
+		if err != nil {
+			return abort(err)
+		}
+		written = append(written, path+".pending")
+	}
+
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return abort(err)
+	}
+	err = os.WriteFile(commit+".tmp", data, 0o644)
+	if err == nil {
+		err = rename(commit+".tmp", commit)
+	}
+	if err != nil {
+		os.Remove(commit + ".tmp")
+		return abort(err)
+	}
+
+	// Committed: from here on, what isn't done now is done by Load
+	for _, path := range paths {
+		err = finishWrite(path)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUnfinished, err)
+		}
+		saves[path].onDisk = stateOf(path)
+	}
+	return nil
+}
+
+// finishWrite puts in place the save pending for path, if its write was
+// committed, and removes the commit file once no save of it is pending.
+// Saves of writes that weren't committed are left alone: they're either
+// being written right now or were abandoned, and a later write replaces them.
+func finishWrite(path string) error {
+	data, err := os.ReadFile(path + ".pending")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var p pending
+	err = json.Unmarshal(data, &p)
+	if err != nil || p.Save == nil {
+		return nil
+	}
+
+	data, err = os.ReadFile(p.Commit)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var paths []string
+	err = json.Unmarshal(data, &paths)
+	if err != nil {
+		return fmt.Errorf("invalid commit file %s: %w", p.Commit, err)
+	}
+
+	err = p.Save.write(path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path + ".pending")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for _, other := range paths {
+		if _, err := os.Stat(other + ".pending"); err == nil {
+			return nil
+		}
+	}
+	err = os.Remove(p.Commit)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package savefile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/stats"
+)
+
+// leftovers are the files of unfinished writes in dir.
+func leftovers(t *testing.T, dir string) []string {
+	t.Helper()
+	found := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".pending") || strings.HasSuffix(name, ".commit") {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return found
+}
+
+func saveOf(trainer string) *Save {
+	s := New()
+	s.Trainer = trainer
+	return s
+}
+
+// renamed loads the save at path and renames its trainer.
+func renamed(t *testing.T, path, trainer string) *Save {
+	t.Helper()
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Trainer = trainer
+	return s
+}
+
+func loadTrainer(t *testing.T, path string) string {
+	t.Helper()
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s.Trainer
+}
+
+func TestWriteAll(t *testing.T) {
+	dir := t.TempDir()
+	ash, misty := TrainerPath(dir, DefaultTrainer), TrainerPath(dir, "misty")
+
+	err := WriteAll(map[string]*Save{ash: saveOf("ash"), misty: saveOf("misty")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := loadTrainer(t, ash); got != "ash" {
+		t.Errorf("expected ash, got %q", got)
+	}
+	if got := loadTrainer(t, misty); got != "misty" {
+		t.Errorf("expected misty, got %q", got)
+	}
+	if files := leftovers(t, dir); len(files) != 0 {
+		t.Errorf("expected no files left behind, got %v", files)
+	}
+}
+
+func TestWriteAllFailsBeforeCommit(t *testing.T) {
+	dir := t.TempDir()
+	ash, misty := TrainerPath(dir, DefaultTrainer), TrainerPath(dir, "misty")
+	err := saveOf("ash").Write(ash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// misty's save can't be written: trainers is a file
+	err = os.WriteFile(filepath.Join(dir, "trainers"), nil, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = WriteAll(map[string]*Save{ash: renamed(t, ash, "ash traded"), misty: saveOf("misty traded")})
+	if err == nil || errors.Is(err, ErrUnfinished) {
+		t.Fatalf("expected the write to fail, got %v", err)
+	}
+	if got := loadTrainer(t, ash); got != "ash" {
+		t.Errorf("expected the save of ash unchanged, got %q", got)
+	}
+	if files := leftovers(t, dir); len(files) != 0 {
+		t.Errorf("expected no files left behind, got %v", files)
+	}
+}
+
+func TestWriteAllFinishedByLoad(t *testing.T) {
+	dir := t.TempDir()
+	ash, misty := TrainerPath(dir, DefaultTrainer), TrainerPath(dir, "misty")
+	err := WriteAll(map[string]*Save{ash: saveOf("ash"), misty: saveOf("misty")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second save can't be put in place, as if the program had crashed
+	rename = func(from, to string) error {
+		if to == misty {
+			return errors.New("disk full")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+
+	err = WriteAll(map[string]*Save{ash: renamed(t, ash, "ash traded"), misty: renamed(t, misty, "misty traded")})
+	if !errors.Is(err, ErrUnfinished) {
+		t.Fatalf("expected ErrUnfinished, got %v", err)
+	}
+	if files := leftovers(t, dir); len(files) != 2 {
+		t.Errorf("expected the save of misty and the commit file pending, got %v", files)
+	}
+
+	rename = os.Rename
+	if got := loadTrainer(t, ash); got != "ash traded" {
+		t.Errorf("expected ash traded, got %q", got)
+	}
+	if got := loadTrainer(t, misty); got != "misty traded" {
+		t.Errorf("expected Load to finish the write, got %q", got)
+	}
+	if files := leftovers(t, dir); len(files) != 0 {
+		t.Errorf("expected no files left behind, got %v", files)
+	}
+}
+
+func TestWriteChanged(t *testing.T) {
+	dir := t.TempDir()
+	ash, misty := TrainerPath(dir, DefaultTrainer), TrainerPath(dir, "misty")
+	err := WriteAll(map[string]*Save{ash: saveOf("ash"), misty: saveOf("misty")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	open := renamed(t, misty, "misty")
+	if open.Changed(misty) {
+		t.Errorf("expected the save just loaded unchanged")
+	}
+
+	// A trade from another Pokedex changes the save of misty
+	err = WriteAll(map[string]*Save{ash: renamed(t, ash, "ash traded"), misty: renamed(t, misty, "misty traded with a longer name")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !open.Changed(misty) {
+		t.Errorf("expected the save open before the trade changed")
+	}
+	err = open.Write(misty)
+	if !errors.Is(err, ErrChanged) {
+		t.Errorf("expected ErrChanged, got %v", err)
+	}
+	err = WriteAll(map[string]*Save{ash: renamed(t, ash, "ash"), misty: open})
+	if !errors.Is(err, ErrChanged) {
+		t.Errorf("expected ErrChanged, got %v", err)
+	}
+	if got := loadTrainer(t, misty); got != "misty traded with a longer name" {
+		t.Errorf("expected the trade kept, got %q", got)
+	}
+	if got := loadTrainer(t, ash); got != "ash traded" {
+		t.Errorf("expected the trade kept, got %q", got)
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	lockWait = 50 * time.Millisecond
+	t.Cleanup(func() { lockWait = 10 * time.Second })
+
+	unlock, err := Lock(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = Lock(dir)
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+	unlock()
+
+	unlock, err = Lock(dir)
+	if err != nil {
+		t.Fatalf("expected the lock released, got %v", err)
+	}
+	unlock()
+
+	// A Pokedex that crashed holding the lock
+	path := filepath.Join(dir, "pokedex.lock")
+	err = os.WriteFile(path, nil, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	err = os.Chtimes(path, old, old)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unlock, err = Lock(dir)
+	if err != nil {
+		t.Fatalf("expected the stale lock taken over, got %v", err)
+	}
+	unlock()
+}
+
+func TestLoadIgnoresUncommittedWrite(t *testing.T) {
+	dir := t.TempDir()
+	ash := TrainerPath(dir, DefaultTrainer)
+	err := saveOf("ash").Write(ash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A write that crashed before committing
+	err = os.WriteFile(ash+".pending", []byte(`{"commit": "`+filepath.Join(dir, ".write-x.commit")+`", "save": {"trainer": "ash traded"}}`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := loadTrainer(t, ash); got != "ash" {
+		t.Errorf("expected the save of ash unchanged, got %q", got)
+	}
+}
+
+// Saves as each version of the Pokedex wrote them
+var olderSaves = map[int]string{
+	1: `{"version": 1, "storage": {"party": [{"name": "pikachu", "id": 25, "types": [{"slot": 1, "type": {"name": "electric"}}]}],
+		"boxes": [[{"name": "onix", "id": 95}]], "current_box": 0}}`,
+	2: `{"version": 2, "storage": {"party": [{"name": "pikachu", "id": 25}], "boxes": []},
+		"pokedex": {"entries": {"pikachu": {"id": 25, "name": "pikachu", "seen": true, "caught": true}, "onix": {"id": 95, "name": "onix", "seen": true}}}}`,
+	3: `{"version": 3, "trainer": "ash", "storage": {"party": [{"name": "pikachu", "id": 25}]}, "pokedex": {"entries": {}},
+		"inventory": {"poke-ball": 7}, "location": "canalave-city-area", "stats": {"areas_explored": 1, "balls_thrown": 4, "caught": 1}}`,
+	4: `{"version": 4, "storage": {"party": []}, "pokedex": {"entries": {}}, "inventory": {"poke-ball": 3},
+		"stats": {"areas_explored": 0, "balls_thrown": 0, "caught": 0},
+		"badges": [{"name": "coal-badge", "region": "sinnoh", "leader": "roark", "at": "2025-06-01T10:00:00Z"}]}`,
+	5: `{"version": 5, "storage": {"party": []}, "pokedex": {"entries": {}}, "inventory": {"poke-ball": 3},
+		"stats": {"areas_explored": 0, "balls_thrown": 0, "caught": 0},
+		"achievements": [{"id": "first-catch", "at": "2025-06-01T10:00:00Z"}]}`,
+}
+
+func loadOlder(t *testing.T, version int) (*Save, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "save.json")
+	err := os.WriteFile(path, []byte(olderSaves[version]), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("version %d: unexpected error: %v", version, err)
+	}
+	if s.Version != CurrentVersion || s.Storage == nil || s.Pokedex == nil || s.Inventory == nil || s.Stats != nil {
+		t.Errorf("version %d: expected a complete save of the current version, got %+v", version, s)
+	}
+	if len(s.Storage.Boxes) != pc.BoxCount {
+		t.Errorf("version %d: expected %d boxes, got %d", version, pc.BoxCount, len(s.Storage.Boxes))
+	}
+	return s, path
+}
+
+func TestLoadVersion1(t *testing.T) {
+	s, _ := loadOlder(t, 1)
+	if all := s.Storage.All(); len(all) != 2 || all[0].Name != "pikachu" || all[1].Name != "onix" {
+		t.Errorf("expected pikachu in the party and onix in a box, got %v", all)
+	}
+	// There was no Pokedex: what we own was caught
+	for _, name := range []string{"pikachu", "onix"} {
+		if e, ok := s.Pokedex.Get(name); !ok || !e.Caught {
+			t.Errorf("expected %s caught, got %+v", name, e)
+		}
+	}
+	if e, _ := s.Pokedex.Get("pikachu"); len(e.Types) != 1 || e.Types[0] != "electric" {
+		t.Errorf("expected the types of pikachu in the Pokedex, got %v", e.Types)
+	}
+	// Nor a bag
+	if s.Inventory[PokeBall] != StartingBalls {
+		t.Errorf("expected %d Poke Balls, got %d", StartingBalls, s.Inventory[PokeBall])
+	}
+}
+
+func TestLoadVersion2(t *testing.T) {
+	s, _ := loadOlder(t, 2)
+	// The Pokedex is kept as it was
+	if e, ok := s.Pokedex.Get("onix"); !ok || !e.Seen || e.Caught {
+		t.Errorf("expected onix seen but not caught, got %+v", e)
+	}
+	if s.Inventory[PokeBall] != StartingBalls {
+		t.Errorf("expected %d Poke Balls, got %d", StartingBalls, s.Inventory[PokeBall])
+	}
+}
+
+func TestLoadVersion3(t *testing.T) {
+	s, path := loadOlder(t, 3)
+	if s.Trainer != "ash" || s.Location != "canalave-city-area" || s.Inventory[PokeBall] != 7 {
+		t.Errorf("expected the profile of ash, got %+v", s)
+	}
+	if len(s.Badges) != 0 {
+		t.Errorf("expected no badges, got %v", s.Badges)
+	}
+
+	// The stats went to the event log
+	events, err := stats.Read(EventLogPath(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summary := stats.Summarize(events)
+	if summary.AreasExplored != 1 || summary.Attempts != 4 || summary.Caught != 1 {
+		t.Errorf("expected the stats of the save in the event log, got %+v", summary)
+	}
+
+	// Loading it again doesn't count them twice
+	_, err = Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, _ = stats.Read(EventLogPath(path))
+	if len(events) != 5 {
+		t.Errorf("expected 5 events, got %d", len(events))
+	}
+}
+
+func TestLoadVersion4(t *testing.T) {
+	s, path := loadOlder(t, 4)
+	if names := s.BadgeNames(); len(names) != 1 || names[0] != "coal-badge" || s.Inventory[PokeBall] != 3 {
+		t.Errorf("expected the coal badge and 3 Poke Balls, got %v and %v", names, s.Inventory)
+	}
+	// Nothing to count, so no log
+	if _, err := os.Stat(EventLogPath(path)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no event log, got %v", err)
+	}
+}
+
+func TestLoadVersion5(t *testing.T) {
+	s, path := loadOlder(t, 5)
+	if ids := s.AchievementIDs(); len(ids) != 1 || ids[0] != "first-catch" {
+		t.Errorf("expected the first-catch achievement, got %v", ids)
+	}
+
+	// Saved again, it has no stats of its own
+	err := s.Write(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), `"stats"`) {
+		t.Errorf("expected no stats in a save of version %d, got %s", CurrentVersion, data)
+	}
+	s, err = Load(path)
+	if err != nil || len(s.Achievements) != 1 {
+		t.Errorf("expected the achievement after saving again, got %v (%v)", s.Achievements, err)
+	}
+}
+
+func TestLoadNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	err := os.WriteFile(path, []byte(`{"version": 99, "storage": {"party": []}, "pets": ["growlithe"]}`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = Load(path)
+	if !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("expected ErrNewerVersion, got %v", err)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "save.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Version != CurrentVersion || len(s.Storage.All()) != 0 || s.Inventory[PokeBall] != StartingBalls {
+		t.Errorf("expected a new game, got %+v", s)
+	}
+}
+
+func TestTrainerPath(t *testing.T) {
+	dir := filepath.Join("home", ".pokedex")
+	if path := TrainerPath(dir, DefaultTrainer); path != filepath.Join(dir, "save.json") {
+		t.Errorf("expected the default trainer in save.json, got %s", path)
+	}
+	if path := TrainerPath(dir, "misty"); path != filepath.Join(dir, "trainers", "misty.json") {
+		t.Errorf("expected misty in trainers/misty.json, got %s", path)
+	}
+}
+
+func TestEventLogPath(t *testing.T) {
+	dir := filepath.Join("home", ".pokedex")
+	if path := EventLogPath(TrainerPath(dir, DefaultTrainer)); path != filepath.Join(dir, "save.events.jsonl") {
+		t.Errorf("expected save.events.jsonl for the default trainer, got %s", path)
+	}
+	if path := EventLogPath(TrainerPath(dir, "misty")); path != filepath.Join(dir, "trainers", "misty.events.jsonl") {
+		t.Errorf("expected trainers/misty.events.jsonl for misty, got %s", path)
+	}
+}
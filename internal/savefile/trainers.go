@@ -0,0 +1,87 @@
+package savefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultTrainer is the profile kept in save.json, the only one before there were profiles.
+// The others are in trainers/<name>.json.
+const DefaultTrainer = "default"
+
+// Trainer names are file names, so they can't have anything that means something in a path.
+var validTrainer = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+var ErrInvalidTrainer = errors.New("trainer names have 1 to 32 letters, digits, - or _")
+
+// ValidTrainer tells whether name can be the name of a profile.
+func ValidTrainer(name string) bool {
+	return validTrainer.MatchString(name)
+}
+
+// TrainerPath is the save file of a trainer in dir.
+func TrainerPath(dir string, trainer string) string {
+	if trainer == DefaultTrainer {
+		return filepath.Join(dir, "save.json")
+	}
+	return filepath.Join(dir, "trainers", trainer+".json")
+}
+
+// EventLogPath is the event log of the trainer with the save file at savePath,
+// e.g. save.events.jsonl for save.json.
+func EventLogPath(savePath string) string {
+	return strings.TrimSuffix(savePath, filepath.Ext(savePath)) + ".events.jsonl"
+}
+
+// Trainers returns the names of the profiles in dir, sorted. The default one is always there.
+func Trainers(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "trainers", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	trainers := []string{DefaultTrainer}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if ValidTrainer(name) && name != DefaultTrainer {
+			trainers = append(trainers, name)
+		}
+	}
+	sort.Strings(trainers[1:])
+	return trainers, nil
+}
+
+// TrainerExists tells whether there is a profile called trainer in dir.
+func TrainerExists(dir string, trainer string) bool {
+	if trainer == DefaultTrainer {
+		return true
+	}
+	_, err := os.Stat(TrainerPath(dir, trainer))
+	return err == nil
+}
+
+// ActiveTrainer is the profile that was being played last time, the default one if unknown.
+func ActiveTrainer(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "trainer"))
+	if err != nil {
+		return DefaultTrainer
+	}
+	name := strings.TrimSpace(string(data))
+	if !ValidTrainer(name) || !TrainerExists(dir, name) {
+		return DefaultTrainer
+	}
+	return name
+}
+
+// SetActiveTrainer remembers the profile being played for the next time.
+func SetActiveTrainer(dir string, trainer string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "trainer"), []byte(trainer+"\n"), 0o644)
+}
@@ -0,0 +1,199 @@
+// Drawing of Pokemon sprites in the terminal.
+//
+// With truecolor every character is two pixels: the upper half block "▀" painted
+// with the colour of the top pixel over the colour of the bottom one. Terminals
+// without truecolor get grayscale ASCII art instead.
+package sprite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+type Mode int
+
+const (
+	TrueColor Mode = iota
+	ASCII
+)
+
+// DetectMode returns TrueColor if the terminal says it supports it (COLORTERM).
+func DetectMode() Mode {
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	return ASCII
+}
+
+// Pixels with less alpha than this are background.
+const transparent = 128
+
+// Characters from the emptiest to the densest. On a dark terminal dense looks bright.
+const ramp = " .:-=+*#%@"
+
+// Cache keeps downloaded sprites in a directory, so each is downloaded once.
+type Cache struct {
+	Dir string
+}
+
+// Get returns the PNG at url, from the cache or from the network.
+func (c Cache) Get(url string) ([]byte, error) {
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(c.Dir, hex.EncodeToString(sum[:8])+".png")
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		// A sprite that isn't a PNG (e.g. cut short) is downloaded again
+		if _, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err = pokeapi.Download(url, "sprite")
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only a cache: if it can't be written, we have the sprite anyway
+	c.put(path, data)
+	return data, nil
+}
+
+// put writes a temporary file and renames it, so the cache never has half a sprite.
+func (c Cache) put(path string, data []byte) error {
+	err := os.MkdirAll(c.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path+".tmp", data, 0o644)
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Decode reads a PNG and crops its transparent border.
+func Decode(data []byte) (image.Image, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the sprite: %w", err)
+	}
+	return Crop(img), nil
+}
+
+func opaque(c color.Color) bool {
+	_, _, _, a := c.RGBA()
+	return a>>8 >= transparent
+}
+
+// Crop returns the smallest part of img that has all its visible pixels.
+func Crop(img image.Image) image.Image {
+	b := img.Bounds()
+	box := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !opaque(img.At(x, y)) {
+				continue
+			}
+			box.Min.X, box.Min.Y = min(box.Min.X, x), min(box.Min.Y, y)
+			box.Max.X, box.Max.Y = max(box.Max.X, x+1), max(box.Max.Y, y+1)
+		}
+	}
+	if box.Empty() {
+		return img
+	}
+
+	cropped := image.NewRGBA(image.Rect(0, 0, box.Dx(), box.Dy()))
+	for y := 0; y < box.Dy(); y++ {
+		for x := 0; x < box.Dx(); x++ {
+			cropped.Set(x, y, img.At(box.Min.X+x, box.Min.Y+y))
+		}
+	}
+	return cropped
+}
+
+// Render draws img with the given mode.
+func Render(w io.Writer, img image.Image, mode Mode) {
+	if mode == ASCII {
+		renderASCII(w, img)
+	} else {
+		renderTrueColor(w, img)
+	}
+}
+
+func rgb(c color.Color) (uint32, uint32, uint32) {
+	r, g, b, _ := c.RGBA()
+	return r >> 8, g >> 8, b >> 8
+}
+
+// renderTrueColor paints two rows of pixels per line of text.
+func renderTrueColor(w io.Writer, img image.Image) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		var line strings.Builder
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top := img.At(x, y)
+			bottom := color.Color(color.Transparent)
+			if y+1 < b.Max.Y {
+				bottom = img.At(x, y+1)
+			}
+
+			switch {
+			case opaque(top) && opaque(bottom):
+				tr, tg, tb := rgb(top)
+				br, bg, bb := rgb(bottom)
+				fmt.Fprintf(&line, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", tr, tg, tb, br, bg, bb)
+			case opaque(top):
+				r, g, b := rgb(top)
+				fmt.Fprintf(&line, "\x1b[49m\x1b[38;2;%d;%d;%dm▀", r, g, b)
+			case opaque(bottom):
+				r, g, b := rgb(bottom)
+				fmt.Fprintf(&line, "\x1b[49m\x1b[38;2;%d;%d;%dm▄", r, g, b)
+			default:
+				line.WriteString("\x1b[0m ")
+			}
+		}
+		line.WriteString("\x1b[0m")
+		fmt.Fprintln(w, line.String())
+	}
+}
+
+// renderASCII uses one character per pixel across and per two pixels down,
+// because characters are about twice as tall as they are wide.
+func renderASCII(w io.Writer, img image.Image) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		var line strings.Builder
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sum, n := 0.0, 0
+			for dy := 0; dy < 2 && y+dy < b.Max.Y; dy++ {
+				c := img.At(x, y+dy)
+				if !opaque(c) {
+					continue
+				}
+				gray := color.GrayModel.Convert(c).(color.Gray)
+				sum += float64(gray.Y)
+				n++
+			}
+			if n == 0 {
+				line.WriteByte(' ')
+				continue
+			}
+			i := 1 + int(sum/float64(n)/256*float64(len(ramp)-1))
+			line.WriteByte(ramp[min(i, len(ramp)-1)])
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+}
@@ -0,0 +1,106 @@
+package sprite
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// newTestImage draws a 2x4 rectangle (white on top, black below) at (3,3) of a 10x10 transparent image.
+func newTestImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 3; y < 7; y++ {
+		for x := 3; x < 5; x++ {
+			c := color.NRGBA{255, 255, 255, 255}
+			if y >= 5 {
+				c = color.NRGBA{0, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCrop(t *testing.T) {
+	cropped := Crop(newTestImage())
+	if cropped.Bounds().Dx() != 2 || cropped.Bounds().Dy() != 4 {
+		t.Errorf("Expected a 2x4 image, but got %v", cropped.Bounds())
+	}
+
+	empty := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	if Crop(empty).Bounds() != empty.Bounds() {
+		t.Errorf("Expected an empty image to be left as it is")
+	}
+}
+
+func TestRenderASCII(t *testing.T) {
+	var out strings.Builder
+	Render(&out, Crop(newTestImage()), ASCII)
+
+	expected := "@@\n..\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestRenderTrueColor(t *testing.T) {
+	var out strings.Builder
+	Render(&out, Crop(newTestImage()), TrueColor)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected two lines (two pixels per character), but got %q", out.String())
+	}
+	if strings.Count(lines[0], "▀") != 2 || !strings.Contains(lines[0], "\x1b[38;2;255;255;255m") {
+		t.Errorf("Expected white half blocks, but got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "\x1b[0m") {
+		t.Errorf("Expected the colours to be reset at the end of the line, but got %q", lines[1])
+	}
+}
+
+func TestCacheRepairsCorruptSprite(t *testing.T) {
+	var sprite bytes.Buffer
+	png.Encode(&sprite, newTestImage())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(sprite.Bytes())
+	}))
+	defer server.Close()
+	pokeapi.DebugOutput = io.Discard
+	defer func() { pokeapi.DebugOutput = os.Stdout }()
+
+	c := Cache{Dir: t.TempDir()}
+	url := server.URL + "/pikachu.png"
+	if _, err := c.Get(url); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(c.Dir, "*"))
+	if len(files) != 1 || filepath.Ext(files[0]) != ".png" {
+		t.Fatalf("expected one cached sprite and no temporary files, got %v", files)
+	}
+
+	// Cut short, as if the Pokedex had crashed while writing it
+	os.WriteFile(files[0], sprite.Bytes()[:20], 0o644)
+	data, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(data); err != nil || requests != 2 {
+		t.Errorf("expected the sprite to be downloaded again, got %v after %d requests", err, requests)
+	}
+	if _, err := c.Get(url); err != nil || requests != 2 {
+		t.Errorf("expected the repaired sprite to be cached, got %v after %d requests", err, requests)
+	}
+}
@@ -0,0 +1,78 @@
+package stats
+
+import "slices"
+
+// Progress is what the achievements are checked against.
+type Progress struct {
+	Summary Summary
+	// Whether the trainer has caught the Pokemon
+	Caught func(name string) bool
+	// Types with every one of their Pokemon caught
+	CompletedTypes []string
+}
+
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	done func(Progress) bool
+}
+
+// The first Pokemon of every region
+var Starters = []string{
+	"bulbasaur", "charmander", "squirtle",
+	"chikorita", "cyndaquil", "totodile",
+	"treecko", "torchic", "mudkip",
+	"turtwig", "chimchar", "piplup",
+	"snivy", "tepig", "oshawott",
+	"chespin", "fennekin", "froakie",
+	"rowlet", "litten", "popplio",
+	"grookey", "scorbunny", "sobble",
+	"sprigatito", "fuecoco", "quaxly",
+}
+
+const EscapeGoal = 100
+
+var Achievements = []Achievement{
+	{
+		ID: "first-catch", Name: "Gotta catch 'em all", Description: "Catch your first Pokemon",
+		done: func(p Progress) bool { return p.Summary.Caught > 0 },
+	},
+	{
+		ID: "kanto-starters", Name: "Professor Oak's table", Description: "Catch bulbasaur, charmander and squirtle",
+		done: func(p Progress) bool { return allCaught(p, Starters[:3]) },
+	},
+	{
+		ID: "all-starters", Name: "Starter collector", Description: "Catch the starters of every region",
+		done: func(p Progress) bool { return allCaught(p, Starters) },
+	},
+	{
+		ID: "type-master", Name: "Type master", Description: "Catch every Pokemon of a type",
+		done: func(p Progress) bool { return len(p.CompletedTypes) > 0 },
+	},
+	{
+		ID: "escape-artist", Name: "Slippery customers", Description: "Let 100 Pokemon escape",
+		done: func(p Progress) bool { return p.Summary.Escapes >= EscapeGoal },
+	},
+}
+
+func allCaught(p Progress, names []string) bool {
+	for _, name := range names {
+		if p.Caught == nil || !p.Caught(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Unlocked returns the achievements done with p that aren't in have yet.
+func Unlocked(p Progress, have []string) []Achievement {
+	unlocked := []Achievement{}
+	for _, a := range Achievements {
+		if !slices.Contains(have, a.ID) && a.done(p) {
+			unlocked = append(unlocked, a)
+		}
+	}
+	return unlocked
+}
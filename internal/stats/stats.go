@@ -0,0 +1,183 @@
+// Statistics of a trainer: an event log of what they do (throwing Poke Balls,
+// exploring, battling), the rates computed from it, and the achievements.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Kinds of Event
+const (
+	// A Poke Ball was thrown and the Pokemon was caught
+	Catch = "catch"
+	// A Poke Ball was thrown and the Pokemon escaped
+	Escape  = "escape"
+	Explore = "explore"
+	Battle  = "battle"
+)
+
+type Event struct {
+	At       time.Time `json:"at"`
+	Kind     string    `json:"kind"`
+	Pokemon  string    `json:"pokemon,omitempty"`
+	Area     string    `json:"area,omitempty"`
+	Ball     string    `json:"ball,omitempty"`
+	Opponent string    `json:"opponent,omitempty"`
+	Won      bool      `json:"won,omitempty"`
+}
+
+// Append adds events to the log at path, one JSON object per line.
+// A last line cut short, e.g. by a crash, is ended first so it doesn't take
+// the first event with it.
+func Append(path string, events ...Event) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		_, err = f.ReadAt(last, info.Size()-1)
+		if err == nil && last[0] != '\n' {
+			_, err = f.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		err = enc.Encode(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Read returns the events of the log at path. A log that doesn't exist yet has none.
+// Lines that aren't events, like one cut short by a crash, are skipped.
+func Read(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Event{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	events := []Event{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e := Event{}
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Rate is how many of the Poke Balls thrown caught the Pokemon.
+type Rate struct {
+	Name     string `json:"name"`
+	Attempts int    `json:"attempts"`
+	Caught   int    `json:"caught"`
+}
+
+func (r Rate) Percent() int {
+	if r.Attempts == 0 {
+		return 0
+	}
+	return r.Caught * 100 / r.Attempts
+}
+
+type Summary struct {
+	Attempts      int `json:"attempts"`
+	Caught        int `json:"caught"`
+	Escapes       int `json:"escapes"`
+	AreasExplored int `json:"areas_explored"`
+	Battles       int `json:"battles"`
+	BattlesWon    int `json:"battles_won"`
+	// Catch rates, the most thrown at first
+	ByBall    []Rate `json:"by_ball"`
+	BySpecies []Rate `json:"by_species"`
+	ByArea    []Rate `json:"by_area"`
+}
+
+func (s Summary) Percent() int {
+	return Rate{Attempts: s.Attempts, Caught: s.Caught}.Percent()
+}
+
+func Summarize(events []Event) Summary {
+	s := Summary{}
+	byBall, bySpecies, byArea := map[string]*Rate{}, map[string]*Rate{}, map[string]*Rate{}
+	count := func(rates map[string]*Rate, name string, caught bool) {
+		if name == "" {
+			return
+		}
+		r, ok := rates[name]
+		if !ok {
+			r = &Rate{Name: name}
+			rates[name] = r
+		}
+		r.Attempts++
+		if caught {
+			r.Caught++
+		}
+	}
+
+	for _, e := range events {
+		switch e.Kind {
+		case Catch, Escape:
+			s.Attempts++
+			if e.Kind == Catch {
+				s.Caught++
+			} else {
+				s.Escapes++
+			}
+			count(byBall, e.Ball, e.Kind == Catch)
+			count(bySpecies, e.Pokemon, e.Kind == Catch)
+			count(byArea, e.Area, e.Kind == Catch)
+		case Explore:
+			s.AreasExplored++
+		case Battle:
+			s.Battles++
+			if e.Won {
+				s.BattlesWon++
+			}
+		}
+	}
+
+	s.ByBall, s.BySpecies, s.ByArea = sorted(byBall), sorted(bySpecies), sorted(byArea)
+	return s
+}
+
+func sorted(rates map[string]*Rate) []Rate {
+	all := []Rate{}
+	for _, r := range rates {
+		all = append(all, *r)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Attempts != all[j].Attempts {
+			return all[i].Attempts > all[j].Attempts
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trainers", "misty.events.jsonl")
+	events, err := Read(path)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected no events before the first one, got %v (%v)", events, err)
+	}
+
+	err = Append(path,
+		Event{Kind: Explore, Area: "canalave-city-area"},
+		Event{Kind: Escape, Pokemon: "wingull", Area: "canalave-city-area", Ball: "poke-ball"},
+		Event{Kind: Catch, Pokemon: "wingull", Area: "canalave-city-area", Ball: "poke-ball"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Append(path,
+		Event{Kind: Escape, Pokemon: "pikachu", Ball: "poke-ball"},
+		Event{Kind: Battle, Opponent: "brock", Won: true},
+		Event{Kind: Battle, Opponent: "misty"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Summarize(events)
+	if s.Attempts != 3 || s.Caught != 1 || s.Escapes != 2 || s.AreasExplored != 1 || s.Battles != 2 || s.BattlesWon != 1 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if len(s.ByBall) != 1 || s.ByBall[0].Percent() != 33 {
+		t.Errorf("expected a 33%% catch rate with poke-ball, got %+v", s.ByBall)
+	}
+	if len(s.BySpecies) != 2 || s.BySpecies[0] != (Rate{Name: "wingull", Attempts: 2, Caught: 1}) {
+		t.Errorf("expected wingull first, got %+v", s.BySpecies)
+	}
+	// pikachu wasn't found exploring
+	if len(s.ByArea) != 1 || s.ByArea[0].Attempts != 2 {
+		t.Errorf("unexpected rates by area %+v", s.ByArea)
+	}
+}
+
+func TestUnlocked(t *testing.T) {
+	caught := map[string]bool{"bulbasaur": true, "charmander": true, "squirtle": true}
+	p := Progress{
+		Summary: Summary{Caught: 3, Escapes: EscapeGoal},
+		Caught:  func(name string) bool { return caught[name] },
+	}
+
+	ids := []string{}
+	for _, a := range Unlocked(p, []string{"first-catch"}) {
+		ids = append(ids, a.ID)
+	}
+	if len(ids) != 2 || ids[0] != "kanto-starters" || ids[1] != "escape-artist" {
+		t.Errorf("expected kanto-starters and escape-artist, got %v", ids)
+	}
+
+	p.CompletedTypes = []string{"ghost"}
+	if a := Unlocked(p, []string{"first-catch", "kanto-starters", "escape-artist"}); len(a) != 1 || a[0].ID != "type-master" {
+		t.Errorf("expected type-master, got %+v", a)
+	}
+}
+
+func TestLogCutShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.events.jsonl")
+	// The last event was being written when the Pokedex crashed
+	err := os.WriteFile(path, []byte(`{"kind":"catch","pokemon":"wingull"}`+"\n"+`{"kind":"esc`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Append(path, Event{Kind: Escape, Pokemon: "pikachu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].Pokemon != "wingull" || events[1].Pokemon != "pikachu" {
+		t.Errorf("expected the events around the broken line, got %+v", events)
+	}
+}
@@ -0,0 +1,145 @@
+// Trades between the trainers of a Pokedex: the offers waiting for an answer
+// and a log of everything that happened to them, kept in one file next to the saves.
+package trade
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/neixir/pokedex/internal/pc"
+)
+
+// What can happen to an offer
+const (
+	Offered   = "offered"
+	Accepted  = "accepted"
+	Declined  = "declined"
+	Cancelled = "cancelled"
+	// The Pokemon were no longer in the parties when it was accepted
+	Rejected = "rejected"
+)
+
+// Offer is From offering one of their Pokemon to To in exchange for one of To's.
+type Offer struct {
+	ID   int    `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	// The Pokemon as they were when the offer was made (see pc.Pokemon.Same)
+	Offered pc.Pokemon `json:"offered"`
+	Wanted  pc.Pokemon `json:"wanted"`
+	At      time.Time  `json:"at"`
+}
+
+// Event is an entry of the log.
+type Event struct {
+	Offer   int       `json:"offer"`
+	Action  string    `json:"action"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Offered string    `json:"offered"`
+	Wanted  string    `json:"wanted"`
+	At      time.Time `json:"at"`
+	// e.g. "kadabra evolved into alakazam"
+	Notes []string `json:"notes,omitempty"`
+}
+
+type Book struct {
+	path   string
+	NextID int     `json:"next_id"`
+	Offers []Offer `json:"offers"`
+	Log    []Event `json:"log"`
+}
+
+// Open loads the book kept in path. If it doesn't exist yet, there have been no trades.
+func Open(path string) (*Book, error) {
+	b := &Book{path: path, NextID: 1, Offers: []Offer{}, Log: []Event{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Save writes the book to a temporary file and renames it, like the saves.
+// Whoever opens the book to change it holds savefile.Lock until it's saved.
+func (b *Book) Save() error {
+	err := os.MkdirAll(filepath.Dir(b.path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(b.path+".tmp", data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(b.path+".tmp", b.path)
+}
+
+// Offer adds a new offer and logs it.
+func (b *Book) Offer(from string, to string, offered pc.Pokemon, wanted pc.Pokemon) Offer {
+	offer := Offer{ID: b.NextID, From: from, To: to, Offered: offered, Wanted: wanted, At: time.Now()}
+	b.NextID++
+	b.Offers = append(b.Offers, offer)
+	b.log(offer, Offered, nil)
+	return offer
+}
+
+// Get returns the offer with that id, if it's still waiting.
+func (b *Book) Get(id int) (Offer, bool) {
+	for _, offer := range b.Offers {
+		if offer.ID == id {
+			return offer, true
+		}
+	}
+	return Offer{}, false
+}
+
+// Pending returns the offers made by or to trainer.
+func (b *Book) Pending(trainer string) []Offer {
+	offers := []Offer{}
+	for _, offer := range b.Offers {
+		if offer.From == trainer || offer.To == trainer {
+			offers = append(offers, offer)
+		}
+	}
+	return offers
+}
+
+// Close removes an offer, logging what happened to it.
+func (b *Book) Close(id int, action string, notes ...string) {
+	for i, offer := range b.Offers {
+		if offer.ID == id {
+			b.Offers = append(b.Offers[:i], b.Offers[i+1:]...)
+			b.log(offer, action, notes)
+			return
+		}
+	}
+}
+
+func (b *Book) log(offer Offer, action string, notes []string) {
+	b.Log = append(b.Log, Event{
+		Offer:   offer.ID,
+		Action:  action,
+		From:    offer.From,
+		To:      offer.To,
+		Offered: offer.Offered.Name,
+		Wanted:  offer.Wanted.Name,
+		At:      time.Now(),
+		Notes:   notes,
+	})
+}
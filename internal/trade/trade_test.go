@@ -0,0 +1,47 @@
+package trade
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/neixir/pokedex/internal/pc"
+)
+
+func TestBook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trades.json")
+	b, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first := b.Offer("ash", "misty", pc.Pokemon{Name: "kadabra"}, pc.Pokemon{Name: "abra"})
+	second := b.Offer("brock", "ash", pc.Pokemon{Name: "onix"}, pc.Pokemon{Name: "pikachu"})
+	b.Offer("brock", "misty", pc.Pokemon{Name: "geodude"}, pc.Pokemon{Name: "staryu"})
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("expected offers 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+	if pending := b.Pending("ash"); len(pending) != 2 {
+		t.Errorf("expected 2 offers for ash, got %d", len(pending))
+	}
+
+	b.Close(first.ID, Accepted, "kadabra evolved into alakazam")
+	if _, ok := b.Get(first.ID); ok {
+		t.Errorf("expected offer 1 to be closed")
+	}
+	err = b.Save()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err = Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(b.Offers) != 2 || b.NextID != 4 {
+		t.Errorf("expected 2 offers and next id 4, got %d and %d", len(b.Offers), b.NextID)
+	}
+	last := b.Log[len(b.Log)-1]
+	if len(b.Log) != 4 || last.Action != Accepted || last.Offered != "kadabra" || len(last.Notes) != 1 {
+		t.Errorf("unexpected log %+v", b.Log)
+	}
+}
@@ -0,0 +1,122 @@
+// Full-screen terminal programs: raw keyboard input and redrawing the whole
+// screen at once. What to draw is up to the program.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/neixir/pokedex/internal/lineedit"
+)
+
+var ErrNotATerminal = errors.New("the full-screen mode needs a terminal")
+
+type Terminal struct {
+	in    *os.File
+	out   *os.File
+	state *term.State
+	keys  *bufio.Reader
+}
+
+// Open switches the terminal to raw mode and to the alternate screen.
+// Close brings it back as it was.
+func Open() (*Terminal, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, ErrNotATerminal
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Terminal{in: os.Stdin, out: os.Stdout, state: state, keys: bufio.NewReader(os.Stdin)}
+	// Alternate screen, hidden cursor
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	return t, nil
+}
+
+func (t *Terminal) Close() error {
+	fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	return term.Restore(int(t.in.Fd()), t.state)
+}
+
+// Size returns the width and height of the terminal, which can change at any time.
+func (t *Terminal) Size() (int, int) {
+	width, height, err := term.GetSize(int(t.out.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// ReadKey waits for a key. See lineedit.ReadKey.
+func (t *Terminal) ReadKey() (rune, error) {
+	return lineedit.ReadKey(t.keys)
+}
+
+// Draw replaces the screen with lines. They must already fit its width.
+func (t *Terminal) Draw(lines []string) {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprint(t.out, b.String())
+}
+
+// Fit pads or cuts s (plain text, without escape sequences) to exactly width characters.
+func Fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		runes := []rune(s)
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// Title is a heading line "── title ─────" of exactly width characters.
+func Title(title string, width int) string {
+	heading := "── " + title + " "
+	n := utf8.RuneCountInString(heading)
+	if n >= width {
+		return Fit(heading, width)
+	}
+	return heading + strings.Repeat("─", width-n)
+}
+
+// Reverse shows s in reverse video, for the selected line of a list.
+func Reverse(s string) string {
+	return "\x1b[7m" + s + "\x1b[0m"
+}
+
+// Bold shows s in bold, for the title of the focused pane.
+func Bold(s string) string {
+	return "\x1b[1m" + s + "\x1b[0m"
+}
+
+// Scroll returns the first line to show of a list of n lines in height lines,
+// so that the cursor is visible.
+func Scroll(cursor int, n int, height int) int {
+	if n <= height || cursor < height/2 {
+		return 0
+	}
+	return min(cursor-height/2, n-height)
+}
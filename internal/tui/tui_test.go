@@ -0,0 +1,50 @@
+package tui
+
+import "testing"
+
+func TestFit(t *testing.T) {
+	cases := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{input: "pikachu", width: 10, expected: "pikachu   "},
+		{input: "pikachu", width: 7, expected: "pikachu"},
+		{input: "pikachu", width: 5, expected: "pika…"},
+		{input: "flabébé", width: 8, expected: "flabébé "},
+		{input: "abc", width: 0, expected: ""},
+	}
+
+	for _, c := range cases {
+		if actual := Fit(c.input, c.width); actual != c.expected {
+			t.Errorf("Expected %q, but got %q", c.expected, actual)
+		}
+	}
+}
+
+func TestScroll(t *testing.T) {
+	cases := []struct {
+		cursor, n, height int
+		expected          int
+	}{
+		{cursor: 0, n: 5, height: 10, expected: 0},
+		{cursor: 3, n: 30, height: 10, expected: 0},
+		{cursor: 12, n: 30, height: 10, expected: 7},
+		{cursor: 29, n: 30, height: 10, expected: 20},
+	}
+
+	for _, c := range cases {
+		if actual := Scroll(c.cursor, c.n, c.height); actual != c.expected {
+			t.Errorf("%+v: expected %d, but got %d", c, c.expected, actual)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if actual := Title("Areas", 12); actual != "── Areas ───" {
+		t.Errorf("Expected %q, but got %q", "── Areas ───", actual)
+	}
+	if actual := Title("Encounters", 8); actual != "── Enco…" {
+		t.Errorf("Expected %q, but got %q", "── Enco…", actual)
+	}
+}
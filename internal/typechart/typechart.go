@@ -0,0 +1,93 @@
+// Type effectiveness chart (generation VI onwards), so matchups can be
+// worked out without asking PokeAPI for every type.
+package typechart
+
+// Types in the order of the games.
+var Types = []string{
+	"normal", "fighting", "flying", "poison", "ground", "rock", "bug", "ghost", "steel",
+	"fire", "water", "grass", "electric", "psychic", "ice", "dragon", "dark", "fairy",
+}
+
+// chart[attack][defense] is the damage multiplier. Missing pairs are 1x.
+var chart = map[string]map[string]float64{
+	"normal":   {"rock": .5, "ghost": 0, "steel": .5},
+	"fighting": {"normal": 2, "flying": .5, "poison": .5, "rock": 2, "bug": .5, "ghost": 0, "steel": 2, "psychic": .5, "ice": 2, "dark": 2, "fairy": .5},
+	"flying":   {"fighting": 2, "rock": .5, "bug": 2, "steel": .5, "grass": 2, "electric": .5},
+	"poison":   {"poison": .5, "ground": .5, "rock": .5, "ghost": .5, "steel": 0, "grass": 2, "fairy": 2},
+	"ground":   {"flying": 0, "poison": 2, "rock": 2, "bug": .5, "steel": 2, "fire": 2, "grass": .5, "electric": 2},
+	"rock":     {"fighting": .5, "flying": 2, "ground": .5, "bug": 2, "steel": .5, "fire": 2, "ice": 2},
+	"bug":      {"fighting": .5, "flying": .5, "poison": .5, "ghost": .5, "steel": .5, "fire": .5, "grass": 2, "psychic": 2, "dark": 2, "fairy": .5},
+	"ghost":    {"normal": 0, "ghost": 2, "psychic": 2, "dark": .5},
+	"steel":    {"rock": 2, "steel": .5, "fire": .5, "water": .5, "electric": .5, "ice": 2, "fairy": 2},
+	"fire":     {"rock": .5, "bug": 2, "steel": 2, "fire": .5, "water": .5, "grass": 2, "ice": 2, "dragon": .5},
+	"water":    {"ground": 2, "rock": 2, "fire": 2, "water": .5, "grass": .5, "dragon": .5},
+	"grass":    {"flying": .5, "poison": .5, "ground": 2, "rock": 2, "bug": .5, "steel": .5, "fire": .5, "water": 2, "grass": .5, "dragon": .5},
+	"electric": {"flying": 2, "ground": 0, "water": 2, "grass": .5, "electric": .5, "dragon": .5},
+	"psychic":  {"fighting": 2, "poison": 2, "steel": .5, "psychic": .5, "dark": 0},
+	"ice":      {"flying": 2, "ground": 2, "steel": .5, "fire": .5, "water": .5, "grass": 2, "ice": .5, "dragon": 2},
+	"dragon":   {"steel": .5, "dragon": 2, "fairy": 0},
+	"dark":     {"fighting": .5, "ghost": 2, "psychic": 2, "dark": .5, "fairy": .5},
+	"fairy":    {"fighting": 2, "poison": .5, "steel": .5, "fire": .5, "dragon": 2, "dark": 2},
+}
+
+// Known reports whether name is one of the 18 types.
+func Known(name string) bool {
+	_, ok := chart[name]
+	return ok
+}
+
+// Effectiveness is the multiplier of an attack of one type against a Pokemon
+// with the given types: 0, 0.25, 0.5, 1, 2 or 4.
+func Effectiveness(attack string, defense ...string) float64 {
+	multiplier := 1.0
+	for _, d := range defense {
+		if m, ok := chart[attack][d]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// Best returns the most effective of the attack types against defense, and its multiplier.
+// With no attack types it returns "" and 1.
+func Best(attack []string, defense []string) (string, float64) {
+	best, multiplier := "", 1.0
+	for i, a := range attack {
+		m := Effectiveness(a, defense...)
+		if i == 0 || m > multiplier {
+			best, multiplier = a, m
+		}
+	}
+	return best, multiplier
+}
+
+// colors are the usual colours of each type, as in the games' interface.
+var colors = map[string][3]uint8{
+	"normal":   {0xA8, 0xA8, 0x78},
+	"fighting": {0xC0, 0x30, 0x28},
+	"flying":   {0xA8, 0x90, 0xF0},
+	"poison":   {0xA0, 0x40, 0xA0},
+	"ground":   {0xE0, 0xC0, 0x68},
+	"rock":     {0xB8, 0xA0, 0x38},
+	"bug":      {0xA8, 0xB8, 0x20},
+	"ghost":    {0x70, 0x58, 0x98},
+	"steel":    {0xB8, 0xB8, 0xD0},
+	"fire":     {0xF0, 0x80, 0x30},
+	"water":    {0x68, 0x90, 0xF0},
+	"grass":    {0x78, 0xC8, 0x50},
+	"electric": {0xF8, 0xD0, 0x30},
+	"psychic":  {0xF8, 0x58, 0x88},
+	"ice":      {0x98, 0xD8, 0xD8},
+	"dragon":   {0x70, 0x38, 0xF8},
+	"dark":     {0x70, 0x58, 0x48},
+	"fairy":    {0xEE, 0x99, 0xAC},
+}
+
+// Color returns the colour of a type. Unknown types are gray.
+func Color(name string) (uint8, uint8, uint8) {
+	c, ok := colors[name]
+	if !ok {
+		return 0x68, 0xA0, 0x90
+	}
+	return c[0], c[1], c[2]
+}
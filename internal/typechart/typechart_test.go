@@ -0,0 +1,52 @@
+package typechart
+
+import "testing"
+
+func TestEffectiveness(t *testing.T) {
+	cases := []struct {
+		attack   string
+		defense  []string
+		expected float64
+	}{
+		{attack: "electric", defense: []string{"water", "flying"}, expected: 4},
+		{attack: "electric", defense: []string{"ground"}, expected: 0},
+		{attack: "fire", defense: []string{"water", "rock"}, expected: .25},
+		{attack: "grass", defense: []string{"water", "ground"}, expected: 4},
+		{attack: "normal", defense: []string{"normal"}, expected: 1},
+		{attack: "ice", defense: []string{"dragon", "flying"}, expected: 4},
+	}
+
+	for _, c := range cases {
+		if actual := Effectiveness(c.attack, c.defense...); actual != c.expected {
+			t.Errorf("%s vs %v: expected %v, but got %v", c.attack, c.defense, c.expected, actual)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	best, m := Best([]string{"water", "flying"}, []string{"rock", "ground"})
+	if best != "water" || m != 4 {
+		t.Errorf("Expected water 4x, but got %s %vx", best, m)
+	}
+
+	best, m = Best(nil, []string{"fire"})
+	if best != "" || m != 1 {
+		t.Errorf("Expected nothing, but got %s %vx", best, m)
+	}
+}
+
+func TestChartIsComplete(t *testing.T) {
+	for _, a := range Types {
+		if !Known(a) {
+			t.Errorf("%s is missing from the chart", a)
+		}
+		if _, ok := colors[a]; !ok {
+			t.Errorf("%s has no colour", a)
+		}
+		for d := range chart[a] {
+			if !Known(d) {
+				t.Errorf("unknown type %s in the row of %s", d, a)
+			}
+		}
+	}
+}
@@ -1,151 +1,445 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
 
+	"github.com/neixir/pokedex/internal/cmdline"
+	"github.com/neixir/pokedex/internal/gym"
+	"github.com/neixir/pokedex/internal/mirror"
+	"github.com/neixir/pokedex/internal/names"
+	"github.com/neixir/pokedex/internal/pc"
 	"github.com/neixir/pokedex/internal/pokeapi"
 	"github.com/neixir/pokedex/internal/pokecache"
+	"github.com/neixir/pokedex/internal/registry"
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/savefile"
+	"github.com/neixir/pokedex/internal/stats"
 )
 
-const PokeApiUrl = "https://pokeapi.co/api/v2/location-area/"
-
-type cliCommand struct {
-	name        string
-	description string
-	callback    func(*Config) error
-}
-
-// This struct will contain the Next and Previous URLs that you'll need to paginate through location areas.
 // CH2 L1 https://www.boot.dev/lessons/813eafe1-2e1d-42a0-b358-53e0f4d4fdc8
 type Config struct {
-	Next               *string
-	Previous           *string
-	Argv               []string
+	// Page of location areas shown by map/mapb: where it starts, its size and
+	// how many areas there are in total
+	areaShown  bool
+	areaOffset int
+	areaLimit  int
+	areaCount  int
+	// The command line being run
+	args               cmdline.Args
 	locationNamesCache *pokecache.Cache
 	pokemonNamesCache  *pokecache.Cache
-	// I used a map[string]Pokemon to keep track of caught Pokemon.
-	caughtPokemon map[string]pokeapi.PokemonType
+	// Caught Pokemon live in the party and PC boxes of the save file,
+	// which is the one of the trainer being played.
+	save     *savefile.Save
+	savePath string
+	trainer  string
+	// Where the Pokedex keeps its files: the save files, names.json, sprites...
+	dir string
+	// Offline copy of PokeAPI, filled by "sync"
+	mirror *mirror.Store
+	// Names of everything in PokeAPI, for search and suggestions (see nameIndex)
+	names *names.Index
+	// Stats of every Pokemon in the mirror, for inspect --graph (see statDistribution)
+	stats    statDistribution
+	commands *registry.Registry[*Config]
+	// For tab completion: every area name seen in map/mapb and the Pokemon of the last explore
+	knownAreas   map[string]bool
+	lastExplored []string
+	// Lowest and highest level of the Pokemon of the last explore, for the ones caught
+	wildLevels map[string][2]int
+	// Gyms of every region, for gym and badges
+	gyms []gym.Region
+	// readLine asks the user for a line, for commands that need an answer (battle).
+	// It's nil when there's nobody to ask, e.g. with -c.
+	readLine func(prompt string) (string, error)
+	output   render.Format
+	// Where the session reads input from and writes results and errors to
+	in     io.Reader
+	out    io.Writer
+	errOut io.Writer
+	// Write each input line after the prompt, as a terminal would (used for transcripts)
+	echo bool
 }
 
-func cleanInput(text string) []string {
-	words := strings.Fields(strings.ToLower(text))
+// persist writes the save file. Commands that change the save call it before returning.
+// If another Pokedex changed the file meanwhile, e.g. with a trade, it's loaded
+// again instead of overwritten, and what the command did is lost.
+func (config *Config) persist() error {
+	unlock, err := savefile.Lock(config.dir)
+	if err != nil {
+		return fmt.Errorf("could not save the game: %w", err)
+	}
+	defer unlock()
 
-	return words
+	err = config.save.Write(config.savePath)
+	if errors.Is(err, savefile.ErrChanged) {
+		save, loadErr := savefile.Load(config.savePath)
+		if loadErr != nil {
+			return fmt.Errorf("could not save the game: %w", errors.Join(err, loadErr))
+		}
+		config.save = save
+		return fmt.Errorf("%w: it was loaded again, try again", err)
+	}
+	if err != nil {
+		return fmt.Errorf("could not save the game: %w", err)
+	}
+	return nil
 }
 
-func commandExit(config *Config) error {
-	fmt.Println("Closing the Pokedex... Goodbye!")
-	os.Exit(0)
-	return nil
+// ErrExit is returned by the exit command to end the session.
+var ErrExit = errors.New("exit")
+
+func commandExit(config *Config) (any, error) {
+	return render.Messagef("Closing the Pokedex... Goodbye!"), ErrExit
+}
+
+type commandHelpResult struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Category    string   `json:"category"`
+	Usage       string   `json:"usage"`
+	Description string   `json:"description"`
+	Help        string   `json:"help,omitempty"`
 }
 
-func commandHelp(config *Config) error {
-	fmt.Println("Welcome to the Pokedex!")
-	fmt.Print("Usage:\n\n")
-	for _, com := range supportedCommands {
-		fmt.Printf("%s: %s\n", com.name, com.description)
+func (r commandHelpResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s\n\n", r.Usage)
+	fmt.Fprintln(w, r.Description)
+	if r.Help != "" {
+		fmt.Fprintf(w, "\n%s\n", r.Help)
 	}
-	return nil
+	if len(r.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(r.Aliases, ", "))
+	}
+}
+
+type helpResult struct {
+	Commands []commandHelpResult `json:"commands"`
+}
+
+func (r helpResult) Text(w io.Writer) {
+	fmt.Fprintln(w, "Welcome to the Pokedex!")
+	fmt.Fprint(w, "Usage:\n")
+	category := ""
+	for _, com := range r.Commands {
+		if com.Category != category {
+			category = com.Category
+			fmt.Fprintf(w, "\n%s:\n", category)
+		}
+		fmt.Fprintf(w, "  %-26s %s\n", com.Usage, com.Description)
+	}
+	fmt.Fprintln(w, "\nType \"help <command>\" for more information about a command.")
 }
 
-func commandMap(config *Config) error {
-	// Aixo de PokeApiUrl ho haura de fer a pokeapi.go
-	url := PokeApiUrl
-	if config.Previous != nil {
-		url = *config.Next
+func (r helpResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, com := range r.Commands {
+		rows = append(rows, []string{com.Category, com.Usage, com.Description})
 	}
+	return []string{"category", "usage", "description"}, rows
+}
+
+func newCommandHelpResult(command *command) commandHelpResult {
+	return commandHelpResult{
+		Name:        command.Name,
+		Aliases:     command.Aliases,
+		Category:    command.Category,
+		Usage:       command.Usage,
+		Description: command.Description,
+		Help:        command.Help,
+	}
+}
 
-	area, err := pokeapi.GetLocationArea(url, config.locationNamesCache)
+func commandHelp(config *Config) (any, error) {
+	if len(config.args.Positional) >= 1 {
+		command, ok := config.commands.Lookup(config.args.Arg(0))
+		if !ok {
+			return nil, fmt.Errorf("no command named %s", config.args.Arg(0))
+		}
+		return newCommandHelpResult(command), nil
+	}
+
+	result := helpResult{Commands: []commandHelpResult{}}
+	for _, group := range config.commands.Groups() {
+		for _, com := range group.Commands {
+			result.Commands = append(result.Commands, newCommandHelpResult(com))
+		}
+	}
+	return result, nil
+}
+
+type areaPage struct {
+	Areas []string `json:"areas"`
+	Page  int      `json:"page"`
+	Pages int      `json:"pages"`
+	Count int      `json:"count"`
+}
+
+func (r areaPage) Text(w io.Writer) {
+	for _, name := range r.Areas {
+		fmt.Fprintln(w, name)
+	}
+	fmt.Fprintf(w, "page %d of %d\n", r.Page, r.Pages)
+}
+
+func (r areaPage) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, name := range r.Areas {
+		rows = append(rows, []string{name})
+	}
+	return []string{"area"}, rows
+}
+
+// pageRangeError is asking for a page after the last one.
+type pageRangeError struct {
+	Pages int
+}
+
+func (e *pageRangeError) Error() string {
+	return fmt.Sprintf("there are only %d pages", e.Pages)
+}
+
+// pageCount is how many pages of limit items are needed for count items.
+func pageCount(count int, limit int) int {
+	return max(1, (count+limit-1)/limit)
+}
+
+// showAreaPage fetches a page of the location-area list and makes it the current one.
+func showAreaPage(config *Config, offset int, limit int) (any, error) {
+	if config.areaShown && offset > 0 && offset >= config.areaCount {
+		return nil, &pageRangeError{Pages: pageCount(config.areaCount, limit)}
+	}
+
+	area, err := pokeapi.GetList(pokeapi.ListURL("location-area", offset, limit), config.locationNamesCache)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	if len(area.Results) == 0 && offset > 0 {
+		return nil, &pageRangeError{Pages: pageCount(area.Count, limit)}
 	}
 
-	// Actualitzem next i previous
-	config.Previous = &area.Previous
-	config.Next = &area.Next
+	// Actualitzem la pagina actual
+	config.areaShown = true
+	config.areaOffset = offset
+	config.areaLimit = limit
+	config.areaCount = area.Count
 
 	// Mostrem els noms
+	result := areaPage{
+		Areas: []string{},
+		Page:  offset/limit + 1,
+		Pages: pageCount(area.Count, limit),
+		Count: area.Count,
+	}
 	for _, loc := range area.Results {
-		fmt.Println(loc.Name)
+		result.Areas = append(result.Areas, loc.Name)
+		config.knownAreas[loc.Name] = true
 	}
 
-	return nil
+	return result, nil
 }
 
-func commandMapB(config *Config) error {
-	// Aixo de PokeApiUrl ho haura de fer a pokeapi.go
-	if config.Previous == nil {
-		fmt.Println("you're on the first page")
-		return nil
+func commandMap(config *Config) (any, error) {
+	args, flags := config.args.Positional, config.args.Flags
+
+	limit := config.areaLimit
+	if value, ok := flags["limit"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid page size: %s", value)
+		}
+		limit = n
 	}
+	// With another page size the old offset means nothing, so we start again
+	restart := !config.areaShown || limit != config.areaLimit
 
-	url := config.Previous
+	if value, ok := flags["page"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid page: %s", value)
+		}
+		return showAreaPage(config, (n-1)*limit, limit)
+	}
 
-	area, err := pokeapi.GetLocationArea(*url, config.locationNamesCache)
-	if err != nil {
-		return nil
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "first":
+			return showAreaPage(config, 0, limit)
+		case "last":
+			if !config.areaShown {
+				// We need the total, which comes with any page
+				_, err := showAreaPage(config, 0, limit)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return showAreaPage(config, (pageCount(config.areaCount, limit)-1)*limit, limit)
+		default:
+			return nil, fmt.Errorf("unknown page %q, use first or last", args[0])
+		}
 	}
 
-	// Actualitzem next i previous
-	config.Previous = &area.Previous
-	config.Next = &area.Next
+	if restart {
+		return showAreaPage(config, 0, limit)
+	}
+	offset := config.areaOffset + limit
+	if offset >= config.areaCount {
+		return render.Messagef("you're on the last page"), nil
+	}
+	return showAreaPage(config, offset, limit)
+}
 
-	//
-	for _, loc := range area.Results {
-		fmt.Println(loc.Name)
+func commandMapB(config *Config) (any, error) {
+	if !config.areaShown || config.areaOffset == 0 {
+		return render.Messagef("you're on the first page"), nil
 	}
 
-	return nil
+	return showAreaPage(config, max(0, config.areaOffset-config.areaLimit), config.areaLimit)
 }
 
-func commandExplore(config *Config) error {
-	var areaName string
+// Poke Balls found exploring when the trainer has none left.
+const foundBalls = 5
 
-	if len(config.Argv) >= 2 {
-		areaName = config.Argv[1]
-	} else {
-		return fmt.Errorf("missing parameter <area name>")
+type exploreResult struct {
+	Area    string   `json:"area"`
+	Pokemon []string `json:"pokemon"`
+	// Poke Balls found
+	Found int `json:"found,omitempty"`
+}
+
+func (r exploreResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Exploring %s...\n", r.Area)
+	fmt.Fprintln(w, "Found Pokemon:")
+	for _, name := range r.Pokemon {
+		fmt.Fprintf(w, "- %s\n", name)
+	}
+	if r.Found > 0 {
+		fmt.Fprintf(w, "You found %d Poke Balls!\n", r.Found)
 	}
+}
 
-	fmt.Printf("Exploring %s...\n", areaName)
+func (r exploreResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, name := range r.Pokemon {
+		rows = append(rows, []string{r.Area, name})
+	}
+	return []string{"area", "pokemon"}, rows
+}
 
-	names, err := pokeapi.GetPokemonNamesByArea(areaName, config.locationNamesCache)
+func commandExplore(config *Config) (any, error) {
+	result, err := config.explore(strings.ToLower(config.args.Arg(0)))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return result, nil
+}
 
-	fmt.Println("Found Pokemon:")
-	for _, name := range names {
-		fmt.Printf("- %s\n", name)
+// explore lists the Pokemon of an area and marks them as seen.
+func (config *Config) explore(areaName string) (exploreResult, error) {
+	areaInfo, err := pokeapi.GetLocationAreaInfo(areaName, config.locationNamesCache)
+	if err != nil {
+		return exploreResult{}, config.didYouMean(err, "location-area", areaName)
 	}
 
-	return nil
+	result := exploreResult{Area: areaName, Pokemon: []string{}}
+	config.lastExplored = []string{}
+	config.wildLevels = map[string][2]int{}
+	for _, encounter := range areaInfo.PokemonEncounters {
+		config.lastExplored = append(config.lastExplored, encounter.Pokemon.Name)
+		if low, high := encounter.Levels(); low > 0 {
+			config.wildLevels[encounter.Pokemon.Name] = [2]int{low, high}
+		}
+		result.Pokemon = append(result.Pokemon, encounter.Pokemon.Name)
+		config.save.Pokedex.MarkSeen(encounter.Pokemon.Name, pokeapi.IDFromURL(encounter.Pokemon.URL))
+	}
+	config.save.Location = areaName
+	err = config.record(stats.Event{Kind: stats.Explore, Area: areaName})
+	if err != nil {
+		return exploreResult{}, err
+	}
+
+	// Other trainers always leave some Poke Balls lying around
+	if config.save.Inventory[savefile.PokeBall] == 0 {
+		config.save.Inventory[savefile.PokeBall] = foundBalls
+		result.Found = foundBalls
+	}
+
+	return result, config.persist()
+}
 
+type catchResult struct {
+	Pokemon string `json:"pokemon"`
+	Caught  bool   `json:"caught"`
+	// 1-based PC box where it was sent because the party was full
+	Box       int `json:"box,omitempty"`
+	BallsLeft int `json:"balls_left"`
+	// Names of the achievements unlocked by this throw
+	Achievements []string `json:"achievements,omitempty"`
+	// Set when the stats could not be updated, the catch counts anyway
+	Warning string `json:"warning,omitempty"`
 }
 
-func commandCatch(config *Config) error {
-	var pokemonName string
+var errNoBalls = errors.New("you're out of Poke Balls, explore an area to look for some")
 
-	if len(config.Argv) >= 2 {
-		pokemonName = config.Argv[1]
+func (r catchResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Throwing a Pokeball at %s...\n", r.Pokemon)
+	if !r.Caught {
+		fmt.Fprintf(w, "%s escaped!\n", r.Pokemon)
 	} else {
-		return fmt.Errorf("missing parameter <pokemon name>")
+		fmt.Fprintf(w, "%s was caught!\n", r.Pokemon)
 	}
+	if r.Box > 0 {
+		fmt.Fprintf(w, "Your party is full, %s was sent to box %d.\n", r.Pokemon, r.Box)
+	}
+	if r.BallsLeft == 0 {
+		fmt.Fprintln(w, "That was your last Poke Ball!")
+	}
+	for _, name := range r.Achievements {
+		fmt.Fprintf(w, "Achievement unlocked: %s!\n", name)
+	}
+	if r.Warning != "" {
+		fmt.Fprintf(w, "(%s)\n", r.Warning)
+	}
+}
 
-	fmt.Printf("Throwing a Pokeball at %s...\n", pokemonName)
+func commandCatch(config *Config) (any, error) {
+	result, err := config.catch(strings.ToLower(config.args.Arg(0)))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
+// catch throws a Pokeball. A caught Pokemon goes to the party, or to the PC if the party is full.
+func (config *Config) catch(pokemonName string) (catchResult, error) {
 	pokemon, err := pokeapi.GetPokemon(pokemonName)
 	if err != nil {
-		return err
+		return catchResult{}, config.didYouMean(err, "pokemon", pokemonName)
+	}
+
+	if config.save.Inventory[savefile.PokeBall] == 0 {
+		return catchResult{}, errNoBalls
+	}
+	if config.save.Storage.Full() {
+		return catchResult{}, pc.ErrStorageFull
 	}
 
 	// fmt.Printf("Trying to catch %s (base experience %d).\n", pokemon.Name, pokemon.BaseExperience)
+	config.save.Pokedex.MarkSeen(pokemon.Name, pokemon.ID)
+	config.save.Inventory[savefile.PokeBall]--
+	result := catchResult{Pokemon: pokemonName, BallsLeft: config.save.Inventory[savefile.PokeBall]}
+	event := stats.Event{Kind: stats.Escape, Pokemon: pokemon.Name, Ball: savefile.PokeBall}
+	if slices.Contains(config.lastExplored, pokemon.Name) {
+		event.Area = config.save.Location
+	}
+	var caught *pc.Pokemon
 
 	// You can use the pokemon's "base experience" to determine the chance of catching it.
 	// The higher the base experience, the harder it should be to catch.
@@ -154,144 +448,124 @@ func commandCatch(config *Config) error {
 	probability := int(100 / math.Pow(float64(pokemon.BaseExperience), 0.2))
 	random := rand.Intn(100)
 	if random < probability {
-		fmt.Printf("%s was caught!\n", pokemonName)
 		// fmt.Printf("%v < %v\n", random, probability)
-		// Once the Pokemon is caught, add it to the user's Pokedex.
-		config.caughtPokemon[pokemonName] = pokemon
-	} else {
-		fmt.Printf("%s escaped!\n", pokemonName)
-		// fmt.Printf("%v >= %v\n", random, probability)
+		// Once the Pokemon is caught, add it to the party (or the PC if the party is full).
+		result.Caught = true
+		p := pc.FromAPI(pokemon)
+		p.Level = config.wildLevel(pokemon.Name)
+		box, err := config.save.Storage.Add(p)
+		if err != nil {
+			// Full() said there was room, but the ball isn't lost for a Pokemon that can't be kept
+			config.save.Inventory[savefile.PokeBall]++
+			return catchResult{}, err
+		}
+		config.save.Pokedex.MarkCaught(p.Name, p.ID, p.Species, p.TypeNames())
+		if box >= 0 {
+			result.Box = box + 1
+		}
+		event.Kind = stats.Catch
+		caught = &p
 	}
 
-	return nil
-
+	// The ball was thrown: the game is saved even if the stats fail
+	err = config.record(event)
+	if err == nil {
+		var unlocked []stats.Achievement
+		unlocked, err = config.unlockAchievements(caught)
+		for _, a := range unlocked {
+			result.Achievements = append(result.Achievements, a.Name)
+		}
+	}
+	if err != nil {
+		result.Warning = fmt.Sprintf("could not update the stats: %v", err)
+	}
+	return result, config.persist()
 }
 
-func commandInspect(config *Config) error {
-	var pokemonName string
+// wildLevel is the level of a wild Pokemon: one of the levels it's found at
+// in the area explored last, or the default one if it's not from there.
+func (config *Config) wildLevel(name string) int {
+	levels, ok := config.wildLevels[name]
+	if !ok {
+		return pc.DefaultLevel
+	}
+	return levels[0] + rand.Intn(levels[1]-levels[0]+1)
+}
 
-	if len(config.Argv) >= 2 {
-		pokemonName = config.Argv[1]
-	} else {
-		return fmt.Errorf("missing parameter <pokemon name>")
+// lookupPokemon gets a Pokemon from PokeAPI by name, or by the nickname of a caught one.
+func (config *Config) lookupPokemon(ref string) (pokeapi.PokemonType, error) {
+	name := strings.ToLower(ref)
+	if caught, ok := config.save.Storage.Find(ref); ok {
+		name = caught.Name
 	}
 
-	pokemon, ok := config.caughtPokemon[pokemonName]
-	if ok {
-		fmt.Printf("Name: %s\n", pokemon.Name)
-		fmt.Printf("Height: %v\n", pokemon.Height)
-		fmt.Printf("Weight: %v\n", pokemon.Weight)
-		fmt.Println("Stats:")
-		for _, stat := range pokemon.Stats {
-			fmt.Printf("  -%v: %v\n", stat.Stat.Name, stat.BaseStat)
-		}
-		fmt.Println("Types:")
-		for _, typ := range pokemon.Types {
-			fmt.Printf("  -%v\n", typ.Type.Name)
-		}
-	} else {
-		fmt.Println("you have not caught that pokemon")
+	pokemon, err := pokeapi.GetPokemon(name)
+	if err != nil {
+		return pokemon, config.didYouMean(err, "pokemon", name)
 	}
+	return pokemon, nil
+}
 
-	return nil
+type statValue struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
 }
 
-// Mostrem els pokemons que s'han obtingut
-func commandPokedex(config *Config) error {
-	if len(config.caughtPokemon) > 0 {
-		fmt.Println("Your Pokedex:")
-		for _, pokemon := range config.caughtPokemon {
-			fmt.Printf("- %s (%d XP)\n", pokemon.Name, pokemon.BaseExperience)
-		}
-	} else {
-		fmt.Println("Your Pokedex is empty :(")
+type inspectResult struct {
+	Name   string      `json:"name"`
+	Height int         `json:"height"`
+	Weight int         `json:"weight"`
+	Stats  []statValue `json:"stats"`
+	Types  []string    `json:"types"`
+}
+
+func (r inspectResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Name: %s\n", r.Name)
+	fmt.Fprintf(w, "Height: %v\n", r.Height)
+	fmt.Fprintf(w, "Weight: %v\n", r.Weight)
+	fmt.Fprintln(w, "Stats:")
+	for _, stat := range r.Stats {
+		fmt.Fprintf(w, "  -%v: %v\n", stat.Name, stat.Value)
+	}
+	fmt.Fprintln(w, "Types:")
+	for _, typ := range r.Types {
+		fmt.Fprintf(w, "  -%v\n", typ)
 	}
+}
 
-	return nil
+func (r inspectResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, stat := range r.Stats {
+		rows = append(rows, []string{stat.Name, fmt.Sprint(stat.Value)})
+	}
+	return []string{"stat", "value"}, rows
 }
 
-var supportedCommands = map[string]cliCommand{}
-
-func main() {
-	config := Config{
-		locationNamesCache: pokecache.NewCache(5 * time.Second),
-		pokemonNamesCache:  pokecache.NewCache(20 * time.Second),
-		caughtPokemon:      map[string]pokeapi.PokemonType{},
-	}
-
-	supportedCommands = map[string]cliCommand{
-		"exit": {
-			name:        "exit",
-			description: "Exit the Pokedex",
-			callback:    commandExit,
-		},
-		"help": {
-			name:        "help",
-			description: "Displays a help message", // "Lists all available commands",
-			callback:    commandHelp,
-		},
-
-		// C2 L1 https://www.boot.dev/lessons/813eafe1-2e1d-42a0-b358-53e0f4d4fdc8
-		"map": {
-			name:        "map",
-			description: "Displays the names of 20 location areas in the Pokemon world",
-			callback:    commandMap,
-		},
-
-		// C2 L1 https://www.boot.dev/lessons/813eafe1-2e1d-42a0-b358-53e0f4d4fdc8
-		"mapb": {
-			name:        "mapb",
-			description: "Displays the 20 names of 20 previous location areas in the Pokemon world",
-			callback:    commandMapB,
-		},
-
-		// C2 L3 https://www.boot.dev/lessons/e53abbb4-5d8a-4feb-ba08-828f03311e51
-		"explore": {
-			name:        "explore",
-			description: "Lists all the pokemon located in an area",
-			callback:    commandExplore,
-		},
-
-		// C2 L4 https://www.boot.dev/lessons/ed962683-cb2d-4989-99e9-5cfa144810b5
-		"catch": {
-			name:        "catch",
-			description: "Catching Pokemon adds them to the user's Pokedex",
-			callback:    commandCatch,
-		},
-
-		// C2 L5 https://www.boot.dev/lessons/0911b406-0b43-4bfe-b60c-177d859093e1
-		"inspect": {
-			name:        "inspect",
-			description: "Prints the name, height, weight, stats and type(s) of the Pokemon",
-			callback:    commandInspect,
-		},
-
-		// C3 L1 https://www.boot.dev/lessons/104a68ca-cea7-42ef-9321-fb8270000db2
-		"pokedex": {
-			name:        "pokedex",
-			description: "Prints a list of all the names of the Pokemon the user has caught",
-			callback:    commandPokedex,
-		},
-	}
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Print("Pokedex > ")
-
-		scanner.Scan()
-		input := scanner.Text()
-		config.Argv = cleanInput(input)
-
-		if len(config.Argv) > 0 {
-			command, ok := supportedCommands[config.Argv[0]]
-			if ok {
-				err := command.callback(&config)
-				if err != nil {
-					fmt.Println(err)
-				}
-			} else {
-				fmt.Println("Unknown command")
-			}
-		}
+func newInspectResult(pokemon pc.Pokemon) inspectResult {
+	result := inspectResult{
+		Name:   pokemon.Name,
+		Height: pokemon.Height,
+		Weight: pokemon.Weight,
+		Stats:  []statValue{},
+		Types:  pokemon.TypeNames(),
+	}
+	for _, stat := range pokemon.Stats {
+		result.Stats = append(result.Stats, statValue{Name: stat.Stat.Name, Value: stat.BaseStat})
+	}
+	return result
+}
+
+func commandInspect(config *Config) (any, error) {
+	pokemonName := config.args.Arg(0)
+
+	pokemon, ok := config.save.Storage.Find(pokemonName)
+	if !ok {
+		return render.Messagef("you have not caught that pokemon"), nil
+	}
+
+	result := newInspectResult(pokemon)
+	if config.args.Bool("graph") {
+		return newStatGraphResult(config, result), nil
 	}
+	return result, nil
 }
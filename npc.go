@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// Trainers met while exploring
+var npcTrainers = []string{
+	"youngster joey", "lass janice", "bug catcher rick", "hiker alan",
+	"camper liam", "picnicker gina", "fisherman andrew", "ace trainer ryan",
+}
+
+const npcTeamSize = 3
+
+// npcTeam is a team of Pokemon found in the area, at the levels they're found at.
+func (config *Config) npcTeam(areaName string, size int, rng *rand.Rand) ([]battle.Pokemon, error) {
+	areaInfo, err := pokeapi.GetLocationAreaInfo(areaName, config.locationNamesCache)
+	if err != nil {
+		return nil, config.didYouMean(err, "location-area", areaName)
+	}
+	if len(areaInfo.PokemonEncounters) == 0 {
+		return nil, fmt.Errorf("there are no Pokemon in %s, nor trainers", areaName)
+	}
+
+	party := []pc.Pokemon{}
+	for range size {
+		encounter := areaInfo.PokemonEncounters[rng.Intn(len(areaInfo.PokemonEncounters))]
+		pokemon, err := pokeapi.GetPokemon(encounter.Pokemon.Name)
+		if err != nil {
+			return nil, err
+		}
+		p := pc.FromAPI(pokemon)
+		p.Level = pc.DefaultLevel
+		if low, high := encounter.Levels(); low > 0 {
+			p.Level = low + rng.Intn(high-low+1)
+		}
+		party = append(party, p)
+	}
+	return battleTeam(party, battle.MaxLevel), nil
+}
+
+func commandChallenge(config *Config) (any, error) {
+	team, err := config.playerTeam()
+	if err != nil {
+		return nil, err
+	}
+
+	area := strings.ToLower(config.args.Arg(0))
+	if area == "" {
+		area = config.save.Location
+	}
+	if area == "" {
+		return nil, fmt.Errorf("there's nobody around, explore an area first")
+	}
+
+	difficulty := battle.Normal
+	if value, ok := config.args.Flag("difficulty"); ok {
+		difficulty = strings.ToLower(value)
+	}
+	size := npcTeamSize
+	if value, ok := config.args.Flag("size"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > pc.PartySize {
+			return nil, fmt.Errorf("invalid team size: %s (from 1 to %d)", value, pc.PartySize)
+		}
+		size = n
+	}
+
+	rng := newBattleRand()
+	ai, err := battle.NewAI(difficulty, rng)
+	if err != nil {
+		return nil, err
+	}
+	npcTeam, err := config.npcTeam(area, size, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	npc := npcTrainers[rng.Intn(len(npcTrainers))]
+	b, err := battle.New([2]string{config.trainer, npc}, [2][]battle.Pokemon{team, npcTeam}, rng)
+	if err != nil {
+		return nil, err
+	}
+	err = battle.Run(b, [2]battle.Player{terminalPlayer{config}, ai})
+	if err != nil {
+		return nil, err
+	}
+
+	return config.recordBattle(b.Result(npc)), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/render"
+)
+
+type storedPokemon struct {
+	Slot           int    `json:"slot"`
+	Name           string `json:"name"`
+	Nickname       string `json:"nickname,omitempty"`
+	BaseExperience int    `json:"base_experience"`
+}
+
+func newStoredPokemons(pokemons []pc.Pokemon) []storedPokemon {
+	stored := []storedPokemon{}
+	for i, pokemon := range pokemons {
+		stored = append(stored, storedPokemon{
+			Slot:           i + 1,
+			Name:           pokemon.Name,
+			Nickname:       pokemon.Nickname,
+			BaseExperience: pokemon.BaseExperience,
+		})
+	}
+	return stored
+}
+
+func (p storedPokemon) String() string {
+	if p.Nickname != "" {
+		return fmt.Sprintf("%s (%s, %d XP)", p.Nickname, p.Name, p.BaseExperience)
+	}
+	return fmt.Sprintf("%s (%d XP)", p.Name, p.BaseExperience)
+}
+
+func storedPokemonTable(pokemons []storedPokemon) ([]string, [][]string) {
+	rows := [][]string{}
+	for _, p := range pokemons {
+		rows = append(rows, []string{fmt.Sprint(p.Slot), p.Name, p.Nickname, fmt.Sprint(p.BaseExperience)})
+	}
+	return []string{"slot", "name", "nickname", "xp"}, rows
+}
+
+type partyResult struct {
+	Party []storedPokemon `json:"party"`
+}
+
+func (r partyResult) Text(w io.Writer) {
+	if len(r.Party) == 0 {
+		fmt.Fprintln(w, "Your party is empty. Go catch some Pokemon!")
+		return
+	}
+
+	fmt.Fprintf(w, "Your party (%d/%d):\n", len(r.Party), pc.PartySize)
+	for _, pokemon := range r.Party {
+		fmt.Fprintf(w, "%d. %s\n", pokemon.Slot, pokemon)
+	}
+}
+
+func (r partyResult) Table() ([]string, [][]string) {
+	return storedPokemonTable(r.Party)
+}
+
+type boxResult struct {
+	Box     int             `json:"box"`
+	Pokemon []storedPokemon `json:"pokemon"`
+}
+
+func (r boxResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Box %d (%d/%d):\n", r.Box, len(r.Pokemon), pc.BoxSize)
+	if len(r.Pokemon) == 0 {
+		fmt.Fprintln(w, "  (empty)")
+	}
+	for _, pokemon := range r.Pokemon {
+		fmt.Fprintf(w, "%d. %s\n", pokemon.Slot, pokemon)
+	}
+}
+
+func (r boxResult) Table() ([]string, [][]string) {
+	return storedPokemonTable(r.Pokemon)
+}
+
+func commandParty(config *Config) (any, error) {
+	return partyResult{Party: newStoredPokemons(config.save.Storage.Party)}, nil
+}
+
+func commandDeposit(config *Config) (any, error) {
+	pokemon, box, err := config.save.Storage.Deposit(config.args.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Messagef("%s was sent to box %d.", pokemon.DisplayName(), box+1), config.persist()
+}
+
+func commandWithdraw(config *Config) (any, error) {
+	pokemon, err := config.save.Storage.Withdraw(config.args.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Messagef("%s joined your party.", pokemon.DisplayName()), config.persist()
+}
+
+func commandSwap(config *Config) (any, error) {
+	err := config.save.Storage.Swap(config.args.Arg(0), config.args.Arg(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Messagef("Swapped %s and %s.", config.args.Arg(0), config.args.Arg(1)), config.persist()
+}
+
+func commandRelease(config *Config) (any, error) {
+	pokemon, err := config.save.Storage.Release(config.args.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Messagef("%s was released. Bye, %s!", pokemon.DisplayName(), pokemon.DisplayName()), config.persist()
+}
+
+// Longest nickname allowed, as in the games since generation VI.
+const maxNicknameLength = 12
+
+func commandNickname(config *Config) (any, error) {
+	nickname := strings.TrimSpace(config.args.Arg(1))
+	if utf8.RuneCountInString(nickname) > maxNicknameLength {
+		return nil, fmt.Errorf("a nickname can have at most %d characters", maxNicknameLength)
+	}
+	// Numbers are party and box slots
+	if _, err := strconv.Atoi(nickname); err == nil {
+		return nil, fmt.Errorf("a nickname can't be a number")
+	}
+
+	pokemon, err := config.save.Storage.Rename(config.args.Arg(0), nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if nickname == "" {
+		return render.Messagef("%s no longer has a nickname.", pokemon.Name), config.persist()
+	}
+	return render.Messagef("%s is now called %s.", pokemon.Name, nickname), config.persist()
+}
+
+func commandBox(config *Config) (any, error) {
+	storage := config.save.Storage
+
+	if len(config.args.Positional) >= 1 {
+		n, err := strconv.Atoi(config.args.Arg(0))
+		if err != nil {
+			return nil, fmt.Errorf("box number must be between 1 and %d", pc.BoxCount)
+		}
+		err = storage.SetBox(n)
+		if err != nil {
+			return nil, fmt.Errorf("box number must be between 1 and %d", pc.BoxCount)
+		}
+	}
+
+	result := boxResult{
+		Box:     storage.CurrentBox + 1,
+		Pokemon: newStoredPokemons(storage.Boxes[storage.CurrentBox]),
+	}
+	return result, config.persist()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/dex"
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// Number of generations we ask PokeAPI for in "pokedex completion".
+const generationCount = 9
+
+type dexLine struct {
+	// National or regional number, 0 if unknown
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	Caught bool   `json:"caught"`
+}
+
+func (l dexLine) String() string {
+	line := fmt.Sprintf("#%03d %s", l.Number, l.Name)
+	if l.Number == 0 {
+		line = fmt.Sprintf("#??? %s", l.Name)
+	}
+	if l.Caught {
+		line += " (caught)"
+	}
+	return line
+}
+
+type pokedexResult struct {
+	Seen    int    `json:"seen"`
+	Caught  int    `json:"caught"`
+	Pokedex string `json:"pokedex"`
+	// Number is the regional one when Pokedex isn't "national"
+	Entries []dexLine `json:"entries"`
+}
+
+func (r pokedexResult) Text(w io.Writer) {
+	if len(r.Entries) == 0 {
+		fmt.Fprintln(w, "Your Pokedex is empty :(")
+		return
+	}
+
+	fmt.Fprintf(w, "Your Pokedex (seen %d, caught %d):\n", r.Seen, r.Caught)
+	if r.Pokedex != "national" {
+		fmt.Fprintf(w, "%s Pokedex:\n", r.Pokedex)
+	}
+	for _, e := range r.Entries {
+		fmt.Fprintln(w, e)
+	}
+}
+
+func (r pokedexResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, e := range r.Entries {
+		rows = append(rows, []string{fmt.Sprint(e.Number), e.Name, fmt.Sprint(e.Caught)})
+	}
+	return []string{"number", "name", "caught"}, rows
+}
+
+type completionRow struct {
+	Label  string `json:"label"`
+	Seen   int    `json:"seen"`
+	Caught int    `json:"caught"`
+	Total  int    `json:"total"`
+}
+
+func (c completionRow) String() string {
+	return fmt.Sprintf("%s: seen %d/%d (%.1f%%), caught %d/%d (%.1f%%)",
+		c.Label, c.Seen, c.Total, percent(c.Seen, c.Total), c.Caught, c.Total, percent(c.Caught, c.Total))
+}
+
+type completionResult struct {
+	Completion []completionRow `json:"completion"`
+}
+
+func (r completionResult) Text(w io.Writer) {
+	for _, row := range r.Completion {
+		fmt.Fprintln(w, row)
+	}
+}
+
+func (r completionResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, c := range r.Completion {
+		rows = append(rows, []string{
+			c.Label,
+			fmt.Sprintf("%d/%d", c.Seen, c.Total),
+			fmt.Sprintf("%.1f%%", percent(c.Seen, c.Total)),
+			fmt.Sprintf("%d/%d", c.Caught, c.Total),
+			fmt.Sprintf("%.1f%%", percent(c.Caught, c.Total)),
+		})
+	}
+	return []string{"pokedex", "seen", "%", "caught", "%"}, rows
+}
+
+// Mostrem els pokemons que s'han vist o obtingut
+func commandPokedex(config *Config) (any, error) {
+	args, flags := config.args.Positional, config.args.Flags
+
+	mode := "caught"
+	if len(args) > 0 {
+		mode = strings.ToLower(args[0])
+	}
+
+	switch mode {
+	case "caught":
+		return listPokedex(config, dex.Caught, flags)
+	case "seen":
+		return listPokedex(config, dex.Seen, flags)
+	case "completion":
+		return pokedexCompletion(config, flags)
+	}
+
+	return nil, fmt.Errorf("unknown pokedex mode %q (use caught, seen or completion)", mode)
+}
+
+func listPokedex(config *Config, filter func(dex.Entry) bool, flags map[string]string) (any, error) {
+	pokedex := config.save.Pokedex
+
+	if typeName, ok := flags["type"]; ok {
+		typeInfo, err := pokeapi.GetType(strings.ToLower(typeName), config.pokemonNamesCache)
+		if err != nil {
+			return nil, err
+		}
+		ofType := map[string]bool{}
+		for _, p := range typeInfo.Pokemon {
+			ofType[p.Pokemon.Name] = true
+		}
+		base := filter
+		filter = func(e dex.Entry) bool {
+			return base(e) && ofType[e.Name]
+		}
+	}
+
+	result := pokedexResult{
+		Seen:    len(pokedex.Sorted(dex.Seen)),
+		Caught:  len(pokedex.Sorted(dex.Caught)),
+		Pokedex: "national",
+		Entries: []dexLine{},
+	}
+	entries := pokedex.Sorted(filter)
+
+	region, ok := flags["region"]
+	if !ok {
+		for _, e := range entries {
+			result.Entries = append(result.Entries, dexLine{Number: e.ID, Name: e.Name, Caught: e.Caught})
+		}
+		return result, nil
+	}
+
+	// Regional numbering: only the Pokemon that belong to that Pokedex, in its order
+	regional, err := pokeapi.GetPokedex(strings.ToLower(region), config.pokemonNamesCache)
+	if err != nil {
+		return nil, err
+	}
+	numbers := map[string]int{}
+	for _, entry := range regional.PokemonEntries {
+		numbers[entry.PokemonSpecies.Name] = entry.EntryNumber
+	}
+
+	result.Pokedex = regional.Name
+	for _, e := range entries {
+		if n := regionalNumber(numbers, e); n > 0 {
+			result.Entries = append(result.Entries, dexLine{Number: n, Name: e.Name, Caught: e.Caught})
+		}
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Number < result.Entries[j].Number
+	})
+
+	return result, nil
+}
+
+func pokedexCompletion(config *Config, flags map[string]string) (any, error) {
+	pokedex := config.save.Pokedex
+	result := completionResult{Completion: []completionRow{}}
+
+	if region, ok := flags["region"]; ok {
+		regional, err := pokeapi.GetPokedex(strings.ToLower(region), config.pokemonNamesCache)
+		if err != nil {
+			return nil, err
+		}
+		species := []string{}
+		for _, entry := range regional.PokemonEntries {
+			species = append(species, entry.PokemonSpecies.Name)
+		}
+		seen, caught := pokedex.Completion(species)
+		result.Completion = append(result.Completion, completionRow{Label: regional.Name, Seen: seen, Caught: caught, Total: len(species)})
+		return result, nil
+	}
+
+	national := completionRow{Label: "national"}
+	for i := 1; i <= generationCount; i++ {
+		generation, err := pokeapi.GetGeneration(fmt.Sprint(i), config.pokemonNamesCache)
+		if err != nil {
+			return nil, err
+		}
+		species := []string{}
+		for _, s := range generation.PokemonSpecies {
+			species = append(species, s.Name)
+		}
+		seen, caught := pokedex.Completion(species)
+		label := fmt.Sprintf("%s (%s)", generation.Name, generation.MainRegion.Name)
+		result.Completion = append(result.Completion, completionRow{Label: label, Seen: seen, Caught: caught, Total: len(species)})
+
+		national.Seen += seen
+		national.Caught += caught
+		national.Total += len(species)
+	}
+	result.Completion = append(result.Completion, national)
+
+	return result, nil
+}
+
+func regionalNumber(numbers map[string]int, e dex.Entry) int {
+	if n, ok := numbers[e.Species]; ok {
+		return n
+	}
+	return numbers[e.Name]
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
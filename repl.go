@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/neixir/pokedex/internal/cassette"
+	"github.com/neixir/pokedex/internal/cmdline"
+	"github.com/neixir/pokedex/internal/gym"
+	"github.com/neixir/pokedex/internal/lineedit"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/pokecache"
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1 // a command failed
+	exitUsage   = 2 // bad command line
+)
+
+// stringList is a flag that can be given more than once (-c "map" -c "map").
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, "; ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run parses the command line and returns the exit code:
+//
+//	pokedex                     interactive REPL
+//	pokedex -c "map" [-c ...]   runs the commands and exits
+//	pokedex run script.pdx      runs a file with one command per line
+//	pokedex tui                 full-screen mode
+//	pokedex serve               JSON API (see runServe)
+func run(args []string) int {
+	flags := flag.NewFlagSet("pokedex", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage:\n  pokedex [options] [-c command]...\n  pokedex [options] run <script>\n  pokedex [options] tui\n  pokedex [options] serve [-addr host:port]\n  pokedex serve-mock [-addr host:port] [-fixtures dir]\n\nOptions:")
+		flags.PrintDefaults()
+	}
+	var commandLines stringList
+	flags.Var(&commandLines, "c", "run a command and exit (can be repeated)")
+	keepGoing := flags.Bool("k", false, "keep going after a command fails (-c and run)")
+	outputFlag := flags.String("output", "text", "output format: text, json, yaml or table")
+	apiURL := flags.String("api", pokeapi.DefaultBaseURL, "PokeAPI base URL (e.g. the one of serve-mock)")
+	cassetteDir := flags.String("cassette", "", "replay (or record) PokeAPI responses from this directory")
+	cassetteFlag := flags.String("cassette-mode", "replay", "with -cassette: replay, record or auto")
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	output, err := render.ParseFormat(*outputFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	pokeapi.SetBaseURL(*apiURL)
+	if *cassetteDir != "" {
+		mode, err := cassette.ParseMode(*cassetteFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		pokeapi.Client.Transport = cassette.New(*cassetteDir, mode)
+	}
+	// Cache messages from pokeapi would break machine-readable output
+	if output != render.Text {
+		pokeapi.DebugOutput = io.Discard
+	}
+
+	// The mock PokeAPI has nothing to do with the game: no save nor mirror to open
+	if len(commandLines) == 0 && flags.NArg() > 0 && flags.Arg(0) == "serve-mock" {
+		return runServeMock(flags.Args()[1:], os.Stderr)
+	}
+
+	trainer := savefile.ActiveTrainer(savefile.Dir())
+	savePath := savefile.TrainerPath(savefile.Dir(), trainer)
+	save, err := savefile.Load(savePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not load save file %s: %v\n", savePath, err)
+		return exitFailure
+	}
+
+	config := newConfig(save, savePath, os.Stdin, os.Stdout, os.Stderr)
+	config.trainer = trainer
+	config.output = output
+	config.mirror, err = openMirror()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring the offline mirror: %v\n", err)
+	}
+
+	switch {
+	case len(commandLines) > 0:
+		if flags.NArg() > 0 {
+			flags.Usage()
+			return exitUsage
+		}
+		return runLines(config, "-c", commandLines, *keepGoing)
+
+	case flags.NArg() > 0 && flags.Arg(0) == "run":
+		if flags.NArg() != 2 {
+			flags.Usage()
+			return exitUsage
+		}
+		return runScript(config, flags.Arg(1), *keepGoing)
+
+	case flags.NArg() == 1 && flags.Arg(0) == "tui":
+		return runTUI(config)
+
+	case flags.NArg() > 0 && flags.Arg(0) == "serve":
+		return runServe(config, flags.Args()[1:])
+
+	case flags.NArg() > 0:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n", flags.Arg(0))
+		flags.Usage()
+		return exitUsage
+	}
+
+	if lineedit.IsTerminal() {
+		return interactive(config)
+	}
+	return repl(config)
+}
+
+func newConfig(save *savefile.Save, savePath string, in io.Reader, out io.Writer, errOut io.Writer) *Config {
+	return &Config{
+		locationNamesCache: pokecache.NewCache(5 * time.Second),
+		pokemonNamesCache:  pokecache.NewCache(20 * time.Second),
+		areaLimit:          20,
+		save:               save,
+		savePath:           savePath,
+		trainer:            savefile.DefaultTrainer,
+		dir:                filepath.Dir(savePath),
+		commands:           newRegistry(),
+		knownAreas:         map[string]bool{},
+		gyms:               gym.Regions(),
+		output:             render.Text,
+		in:                 in,
+		out:                out,
+		errOut:             errOut,
+	}
+}
+
+// prompt shows the trainer being played, unless it's the default one.
+func (config *Config) prompt() string {
+	if config.trainer == savefile.DefaultTrainer {
+		return "Pokedex > "
+	}
+	return fmt.Sprintf("Pokedex (%s) > ", config.trainer)
+}
+
+// execute runs one line of input and shows its result.
+// The result of exit is shown too, before returning ErrExit.
+func (config *Config) execute(line string) error {
+	args, err := cmdline.Parse(line)
+	if err != nil {
+		return err
+	}
+	config.args = args
+
+	// Another Pokedex may have changed the save since, e.g. with a trade
+	if config.save.Changed(config.savePath) {
+		save, err := savefile.Load(config.savePath)
+		if err != nil {
+			return err
+		}
+		config.save = save
+	}
+
+	// The registry gets the command and the positional arguments, which are the ones it counts
+	argv := []string{}
+	if args.Command != "" {
+		argv = append([]string{args.Command}, args.Positional...)
+	}
+	result, err := config.commands.Run(config, argv)
+	if err != nil && !errors.Is(err, ErrExit) {
+		return err
+	}
+	renderErr := render.Render(config.out, config.output, result)
+	if err != nil {
+		return err
+	}
+	return renderErr
+}
+
+// interactive is the REPL with line editing, for terminals.
+func interactive(config *Config) int {
+	editor := lineedit.New(filepath.Join(savefile.Dir(), "history"))
+	editor.Completer = config.complete
+	config.readLine = editor.ReadLine
+	for {
+		input, err := editor.ReadLine(config.prompt())
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err != nil {
+			// Ctrl-D
+			config.execute("exit")
+			return exitOK
+		}
+		editor.AddHistory(input)
+
+		err = config.execute(input)
+		if errors.Is(err, ErrExit) {
+			return exitOK
+		}
+		if err != nil {
+			fmt.Fprintln(config.out, err)
+		}
+	}
+}
+
+// repl is the loop used when the input is not a terminal (a pipe, a file, a test).
+// It stops at EOF or exit, and the exit code tells whether any command failed.
+func repl(config *Config) int {
+	code := exitOK
+
+	scanner := bufio.NewScanner(config.in)
+	config.readLine = func(prompt string) (string, error) {
+		fmt.Fprint(config.out, prompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(config.out)
+			return "", io.EOF
+		}
+		if config.echo {
+			fmt.Fprintln(config.out, scanner.Text())
+		}
+		return scanner.Text(), nil
+	}
+
+	for {
+		line, err := config.readLine(config.prompt())
+		if err != nil {
+			break
+		}
+
+		err = config.execute(line)
+		if errors.Is(err, ErrExit) {
+			return code
+		}
+		if err != nil {
+			fmt.Fprintln(config.out, err)
+			code = exitFailure
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(config.errOut, err)
+		return exitFailure
+	}
+	return code
+}
+
+// runScript runs a command file. Empty lines and lines starting with # are skipped.
+func runScript(config *Config, path string, keepGoing bool) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(config.errOut, err)
+		return exitFailure
+	}
+
+	return runLines(config, path, strings.Split(string(data), "\n"), keepGoing)
+}
+
+// runLines runs commands without prompt. Errors go to stderr prefixed with
+// source:line, and unless keepGoing the first one stops the run.
+func runLines(config *Config, source string, lines []string, keepGoing bool) int {
+	code := exitOK
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := config.execute(line)
+		if errors.Is(err, ErrExit) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(config.errOut, "%s:%d: %v\n", source, i+1, err)
+			code = exitFailure
+			if !keepGoing {
+				break
+			}
+		}
+	}
+
+	return code
+}
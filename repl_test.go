@@ -1,39 +1,939 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
 
-func TestCleanInput(t *testing.T) {
-	// ...
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/cassette"
+	"github.com/neixir/pokedex/internal/cmdline"
+	"github.com/neixir/pokedex/internal/gym"
+	"github.com/neixir/pokedex/internal/lineedit"
+	"github.com/neixir/pokedex/internal/mirror"
+	"github.com/neixir/pokedex/internal/mockapi"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+func TestComplete(t *testing.T) {
+	config := newTestConfig(t)
+	config.knownAreas = map[string]bool{"pastoria-city-area": true, "canalave-city-area": true}
+	config.lastExplored = []string{"tentacool", "magikarp"}
+	config.save.Storage.Add(pc.Pokemon{Name: "pikachu"})
+	config.save.Storage.Add(pc.Pokemon{Name: "magikarp", Nickname: "Big Fish"})
 
 	cases := []struct {
-		input    string
+		line     string
 		expected []string
 	}{
-		{
-			input:    "  hello  world  ",
-			expected: []string{"hello", "world"},
-		},
-		// add more cases here
+		{line: "ma", expected: []string{"map", "mapb"}},
+		{line: "explore pa", expected: []string{"pastoria-city-area"}},
+		{line: "catch ", expected: []string{"magikarp", "tentacool"}},
+		{line: "inspect p", expected: []string{"pikachu"}},
+		{line: "help ex", expected: []string{"exit", "explore"}},
+		{line: `nickname "bi`, expected: []string{`"big fish"`}},
+		{line: `nickname big\ f`, expected: []string{`"big fish"`}},
+		{line: `nickname 'Big F`, expected: []string{`"big fish"`}},
+		// "big fish" is one word, so next is the trainer
+		{line: `trade offer "big fish" `, expected: []string{"default"}},
+	}
+
+	for _, c := range cases {
+		_, actual := config.complete(c.line)
+		if strings.Join(actual, ",") != strings.Join(c.expected, ",") {
+			t.Errorf("%q: expected %v, but got %v", c.line, c.expected, actual)
+		}
+	}
+}
+
+// newTestConfig returns a session with a new game whose output is discarded.
+func newTestConfig(t *testing.T) *Config {
+	savePath := filepath.Join(t.TempDir(), "save.json")
+	return newConfig(savefile.New(), savePath, strings.NewReader(""), io.Discard, io.Discard)
+}
+
+func TestRunLinesExitCode(t *testing.T) {
+	cases := []struct {
+		lines     []string
+		keepGoing bool
+		expected  int
+	}{
+		{lines: []string{"# comment", "", "party"}, expected: exitOK},
+		{lines: []string{"foo", "party"}, expected: exitFailure},
+		{lines: []string{"explore"}, expected: exitFailure},
+		{lines: []string{"foo", "party"}, keepGoing: true, expected: exitFailure},
 	}
 
 	for _, c := range cases {
-		actual := cleanInput(c.input)
-		// Check the length of the actual slice against the expected slice
-		// if they don't match, use t.Errorf to print an error message
-		// and fail the test
-		if len(actual) != len(c.expected) {
-			t.Errorf("Expected %d words, but got %d", len(c.expected), len(actual))
-		}
-		for i := range actual {
-			word := actual[i]
-			expectedWord := c.expected[i]
-			// Check each word in the slice
-			// if they don't match, use t.Errorf to print an error message
-			// and fail the test
-			if word != expectedWord {
-				t.Errorf("Expected %q, but got %q", expectedWord, word)
+		actual := runLines(newTestConfig(t), "test", c.lines, c.keepGoing)
+		if actual != c.expected {
+			t.Errorf("%v: expected exit code %d, but got %d", c.lines, c.expected, actual)
+		}
+	}
+}
+
+func TestExitStopsTheSession(t *testing.T) {
+	config := newTestConfig(t)
+	config.in = strings.NewReader("foo\nexit\nparty\n")
+
+	var out bytes.Buffer
+	config.out = &out
+
+	code := repl(config)
+	if code != exitFailure {
+		t.Errorf("expected exit code %d after a failed command, but got %d", exitFailure, code)
+	}
+	if strings.Contains(out.String(), "Your party") {
+		t.Errorf("expected the session to stop at exit, got:\n%s", out.String())
+	}
+}
+
+func TestQuotedArguments(t *testing.T) {
+	config := newTestConfig(t)
+	config.save.Storage.Add(pc.Pokemon{Name: "pikachu"})
+
+	var out bytes.Buffer
+	config.out = &out
+
+	lines := []string{
+		`NICKNAME Pikachu "Sir Sparks"`,
+		`inspect sir\ sparks`,
+		`party`,
+	}
+	code := runLines(config, "test", lines, false)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, but got %d:\n%s", exitOK, code, out.String())
+	}
+	for _, expected := range []string{"pikachu is now called Sir Sparks.", "Name: pikachu", "1. Sir Sparks (pikachu"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output, got:\n%s", expected, out.String())
+		}
+	}
+
+	err := config.execute(`nickname pikachu "unterminated`)
+	if err == nil {
+		t.Errorf("expected an error for an unterminated quote")
+	}
+}
+
+func TestInspectGraph(t *testing.T) {
+	config := newTestConfig(t)
+	pikachu := pc.Pokemon{Name: "pikachu", Height: 4, Weight: 60, Types: []pokeapi.Types{{Type: pokeapi.Type{Name: "electric"}}}}
+	for _, stat := range []struct {
+		name  string
+		value int
+	}{{"hp", 35}, {"attack", 55}, {"speed", 90}} {
+		pikachu.Stats = append(pikachu.Stats, pokeapi.Stats{BaseStat: stat.value, Stat: pokeapi.Stat{Name: stat.name}})
+	}
+	config.save.Storage.Add(pikachu)
+	config.stats = statDistribution{"hp": {20, 35, 50, 80}, "total": {100, 180, 200, 500}}
+
+	var out bytes.Buffer
+	config.out = &out
+	err := config.execute("inspect pikachu --graph")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The output is not a terminal, so there are no colours
+	expected := []string{
+		"pikachu  [electric]\n",
+		"hp                35 #####                                    higher than 25%\n",
+		"speed             90 ##############\n",
+		"total            180                                          higher than 25%\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out.String(), e) {
+			t.Errorf("expected %q in the output, got:\n%s", e, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("expected no colours, got:\n%s", out.String())
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden transcripts in testdata/transcripts")
+
+// TestTranscripts replays the sessions in testdata/transcripts. A transcript is what
+// the user sees on the terminal: the lines starting with the prompt are the input,
+// the rest is the expected output. Transcripts must end with exit.
+func TestTranscripts(t *testing.T) {
+	files, err := filepath.Glob("testdata/transcripts/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayCassettes(t)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			golden, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
 			}
+
+			var out bytes.Buffer
+			config := newTestConfig(t)
+			config.in = strings.NewReader(transcriptInput(string(golden)))
+			config.out = &out
+			config.echo = true
+
+			repl(config)
+
+			if *update {
+				os.WriteFile(file, out.Bytes(), 0o644)
+				return
+			}
+			if out.String() != string(golden) {
+				t.Errorf("transcript mismatch.\nExpected:\n%s\nGot:\n%s", golden, out.String())
+			}
+		})
+	}
+}
+
+// replayCassettes answers PokeAPI requests with the recordings in testdata/cassettes until the test ends.
+func replayCassettes(t *testing.T) {
+	pokeapi.Client.Transport = cassette.New("testdata/cassettes", cassette.Replay)
+	pokeapi.DebugOutput = io.Discard
+	t.Cleanup(func() {
+		pokeapi.Client.Transport = nil
+		pokeapi.DebugOutput = os.Stdout
+	})
+}
+
+func TestTUI(t *testing.T) {
+	replayCassettes(t)
+	m := newTUIModel(newTestConfig(t))
+	m.showPage(0)
+
+	// Open pastoria-city-area, the third area, and then its first Pokemon
+	for _, key := range []rune{lineedit.KeyDown, lineedit.KeyDown, lineedit.KeyEnter, lineedit.KeyEnter} {
+		m.handle(key)
+	}
+	if m.focus != paneEncounters || m.area != "pastoria-city-area" || len(m.encounters) != 10 {
+		t.Fatalf("expected the encounters of pastoria-city-area, got %q in %q (%s)", m.encounters, m.area, m.status)
+	}
+	if m.detail == nil || m.detail.Name != "tentacool" {
+		t.Fatalf("expected tentacool in the detail pane (%s)", m.status)
+	}
+	if entry, _ := m.config.save.Pokedex.Get("gastrodon"); !entry.Seen {
+		t.Errorf("expected the Pokemon of the area to be seen")
+	}
+
+	lines := m.view(120, 30)
+	if len(lines) != 30 {
+		t.Errorf("expected 30 lines, got %d", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, expected := range []string{"Areas 1/1", "Encounters in pastoria-city-area", "#072 tentacool  [water/poison]", "Caught 0"} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("expected %q on the screen:\n%s", expected, screen)
 		}
 	}
 
+	m.handle('q')
+	if !m.quit {
+		t.Errorf("expected q to quit")
+	}
+}
+
+func transcriptInput(transcript string) string {
+	var input strings.Builder
+	for _, line := range strings.Split(transcript, "\n") {
+		if command, ok := strings.CutPrefix(line, "Pokedex > "); ok {
+			input.WriteString(command + "\n")
+		}
+	}
+	return input.String()
+}
+
+func TestServe(t *testing.T) {
+	replayCassettes(t)
+	base := newTestConfig(t)
+	api := newAPIServer(base)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	call := func(method string, path string, token string, body string, v any) int {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if v != nil {
+			json.NewDecoder(res.Body).Decode(v)
+		}
+		return res.StatusCode
+	}
+	login := func(user string) string {
+		t.Helper()
+		var session sessionResult
+		if status := call("POST", "/sessions", "", `{"user": "`+user+`"}`, &session); status != http.StatusCreated {
+			t.Fatalf("Expected 201 logging in as %s, but got %d", user, status)
+		}
+		return session.Token
+	}
+
+	if status := call("GET", "/pokedex", "", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a session, but got %d", status)
+	}
+	if status := call("POST", "/sessions", "", `{"user": "../ash"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad user name, but got %d", status)
+	}
+	ash, misty := login("ash"), login("misty")
+
+	var page areaPage
+	if status := call("GET", "/areas?limit=20", ash, "", &page); status != http.StatusOK || len(page.Areas) != 20 {
+		t.Fatalf("Expected a page of 20 areas, but got %d %v", status, page.Areas)
+	}
+	if status := call("GET", "/areas?page=99", ash, "", nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a page that doesn't exist, but got %d", status)
+	}
+	if status := call("GET", "/areas?limit=1000000", ash, "", nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a limit over %d, but got %d", maxAreaLimit, status)
+	}
+	if status := call("GET", "/areas?limit=100&page="+strconv.Itoa(math.MaxInt), ash, "", nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a page past the last possible one, but got %d", status)
+	}
+	if status := call("POST", "/catch", ash, `{"pokemon": "`+strings.Repeat("a", maxBody)+`"}`, nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a body over %d bytes, but got %d", maxBody, status)
+	}
+
+	var explored exploreResult
+	if status := call("GET", "/areas/pastoria-city-area", ash, "", &explored); status != http.StatusOK || len(explored.Pokemon) != 10 {
+		t.Errorf("Expected the 10 Pokemon of pastoria-city-area, but got %d %v", status, explored.Pokemon)
+	}
+
+	var pokemon pokemonResult
+	if status := call("GET", "/pokemon/pikachu", ash, "", &pokemon); status != http.StatusOK || pokemon.ID != 25 || pokemon.Caught {
+		t.Errorf("Expected pikachu (25, not caught), but got %d %+v", status, pokemon)
+	}
+	if status := call("GET", "/pokemon/pikchu", ash, "", nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for pikchu, but got %d", status)
+	}
+
+	// Catching is random, but magikarp doesn't resist much
+	caught := false
+	for i := 0; i < savefile.StartingBalls && !caught; i++ {
+		var result catchResult
+		if status := call("POST", "/catch", ash, `{"pokemon": "magikarp"}`, &result); status != http.StatusOK {
+			t.Fatalf("Expected 200 catching magikarp, but got %d", status)
+		}
+		caught = result.Caught
+	}
+	if !caught {
+		t.Fatal("magikarp was never caught")
+	}
+
+	// Each user has their own Pokedex and save file
+	var dex pokedexResult
+	call("GET", "/pokedex", ash, "", &dex)
+	if len(dex.Entries) != 1 || dex.Entries[0].Name != "magikarp" {
+		t.Errorf("Expected magikarp in the Pokedex of ash, but got %v", dex.Entries)
+	}
+	call("GET", "/pokedex?mode=seen", misty, "", &dex)
+	if len(dex.Entries) != 0 {
+		t.Errorf("Expected the Pokedex of misty to be empty, but got %v", dex.Entries)
+	}
+	if status := call("GET", "/pokedex?mode=everything", misty, "", nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown mode, but got %d", status)
+	}
+	save, err := savefile.Load(savefile.TrainerPath(base.dir, "ash"))
+	if err != nil || len(save.Storage.All()) != 1 {
+		t.Fatalf("Expected the save of ash to have magikarp (%v)", err)
+	}
+
+	// The save changed somewhere else, e.g. in the REPL
+	save.Pokedex.MarkCaught("pikachu", 25, "pikachu", []string{"electric"})
+	err = save.Write(savefile.TrainerPath(base.dir, "ash"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := call("GET", "/pokemon/pikachu", ash, "", &pokemon); status != http.StatusOK || !pokemon.Caught {
+		t.Errorf("Expected pikachu caught after the save changed, but got %d %+v", status, pokemon)
+	}
+
+	api.mu.Lock()
+	api.tokens[misty] = sessionToken{user: "misty", expires: time.Now().Add(-time.Minute)}
+	api.mu.Unlock()
+	if status := call("GET", "/pokedex", misty, "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with an expired token, but got %d", status)
+	}
+	// Logging in sweeps the tokens that expired and nobody used again
+	api.mu.Lock()
+	api.tokens["forgotten"] = sessionToken{user: "misty", expires: time.Now().Add(-time.Minute)}
+	api.mu.Unlock()
+	login("misty")
+	api.mu.Lock()
+	_, ok := api.tokens["forgotten"]
+	api.mu.Unlock()
+	if ok {
+		t.Errorf("Expected the expired token swept when logging in")
+	}
+
+	if status := call("DELETE", "/sessions", ash, "", nil); status != http.StatusNoContent {
+		t.Errorf("Expected 204 logging out, but got %d", status)
+	}
+	if status := call("GET", "/pokedex", ash, "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after logging out, but got %d", status)
+	}
+}
+
+func TestTrainers(t *testing.T) {
+	replayCassettes(t)
+	config := newTestConfig(t)
+
+	run := func(line string) {
+		t.Helper()
+		err := config.execute(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+
+	run("explore pastoria-city-area")
+	run("catch magikarp")
+	if config.prompt() != "Pokedex > " {
+		t.Errorf("Expected the default prompt, but got %q", config.prompt())
+	}
+
+	run("trainer new Misty")
+	if config.prompt() != "Pokedex (misty) > " {
+		t.Errorf("Expected the prompt of misty, but got %q", config.prompt())
+	}
+	if savefile.ActiveTrainer(config.dir) != "misty" {
+		t.Errorf("Expected misty to be remembered as the active trainer")
+	}
+	if n := len(config.save.Storage.All()); n != 0 || config.save.Location != "" || config.save.Inventory[savefile.PokeBall] != savefile.StartingBalls {
+		t.Errorf("Expected a new game for misty, but got %d Pokemon in %q", n, config.save.Location)
+	}
+	if err := config.execute("trainer new misty"); err == nil {
+		t.Errorf("Expected an error creating misty twice")
+	}
+	if err := config.execute("trainer switch brock"); err == nil {
+		t.Errorf("Expected an error switching to a trainer that doesn't exist")
+	}
+
+	config.args, _ = cmdline.Parse("trainer list")
+	result, err := commandTrainer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := result.(trainerListResult)
+	if len(list.Trainers) != 2 || list.Trainers[0].Name != "default" || list.Trainers[0].Location != "pastoria-city-area" || !list.Trainers[1].Active {
+		t.Errorf("Unexpected trainer list %+v", list.Trainers)
+	}
+
+	run("trainer switch default")
+	config.args, _ = cmdline.Parse("trainer")
+	result, err = commandTrainer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer := result.(trainerResult)
+	if trainer.Location != "pastoria-city-area" || trainer.Stats.BallsThrown != 1 || trainer.Inventory[savefile.PokeBall] != savefile.StartingBalls-1 {
+		t.Errorf("Expected the game of the default trainer back, but got %+v", trainer)
+	}
+}
+
+func TestCatchWithoutBalls(t *testing.T) {
+	replayCassettes(t)
+	config := newTestConfig(t)
+	config.save.Inventory[savefile.PokeBall] = 0
+
+	_, err := config.catch("magikarp")
+	if !errors.Is(err, errNoBalls) {
+		t.Errorf("Expected %v, but got %v", errNoBalls, err)
+	}
+
+	result, err := config.explore("pastoria-city-area")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Found != foundBalls || config.save.Inventory[savefile.PokeBall] != foundBalls {
+		t.Errorf("Expected to find %d Poke Balls, but found %d", foundBalls, result.Found)
+	}
+}
+
+// serveMockAPI points PokeAPI requests to the bundled fixtures of serve-mock until the test ends.
+func serveMockAPI(t *testing.T) {
+	s, err := mockapi.New(mockapi.Fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	pokeapi.SetBaseURL(server.URL + "/api/v2/")
+	pokeapi.DebugOutput = io.Discard
+	t.Cleanup(func() {
+		server.Close()
+		pokeapi.SetBaseURL(pokeapi.DefaultBaseURL)
+		pokeapi.DebugOutput = os.Stdout
+	})
+}
+
+// serve-mock doesn't touch the game, so a save it can't load doesn't stop it
+func TestServeMockWithoutSave(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("POKEDEX_HOME", dir)
+	err := os.WriteFile(filepath.Join(dir, "save.json"), []byte(`{"version": 999}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad flag, so it doesn't start serving
+	if code := run([]string{"serve-mock", "-bogus"}); code != exitUsage {
+		t.Errorf("Expected the usage exit code of serve-mock, but got %d", code)
+	}
+}
+
+func TestTrade(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+
+	give := func(names ...string) {
+		t.Helper()
+		for _, name := range names {
+			pokemon, err := pokeapi.GetPokemon(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.save.Storage.Add(pc.FromAPI(pokemon))
+		}
+		config.persist()
+	}
+	run := func(line string) {
+		t.Helper()
+		err := config.execute(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+
+	give("kadabra", "pikachu", "eevee", "onix", "geodude", "staryu", "squirtle")
+	run("trainer new misty")
+	give("abra")
+	run("trainer switch default")
+
+	// squirtle went to the PC, only party Pokemon can be traded
+	if err := config.execute("trade offer squirtle misty abra"); err == nil || !strings.Contains(err.Error(), "only Pokemon in the party") {
+		t.Errorf("Expected an error trading a Pokemon of the PC, but got %v", err)
+	}
+	if err := config.execute("trade offer kadabra misty pikachu"); err == nil {
+		t.Errorf("Expected an error asking for a Pokemon misty doesn't have")
+	}
+	run("trade offer kadabra misty abra")
+	if err := config.execute("trade accept"); err == nil {
+		t.Errorf("Expected an error accepting your own offer")
+	}
+
+	run("trainer switch misty")
+	var out bytes.Buffer
+	config.out = &out
+	run("trade accept")
+	if !strings.Contains(out.String(), "kadabra evolved into alakazam") {
+		t.Errorf("Expected kadabra to evolve, but got %q", out.String())
+	}
+	if config.save.Storage.Party[0].Name != "alakazam" {
+		t.Errorf("Expected misty to have alakazam, but got %s", config.save.Storage.Party[0].Name)
+	}
+	if entry, _ := config.save.Pokedex.Get("alakazam"); !entry.Caught {
+		t.Errorf("Expected alakazam to be caught in the Pokedex of misty")
+	}
+
+	run("trainer switch default")
+	if config.save.Storage.Party[0].Name != "abra" || len(config.save.Storage.Party) != pc.PartySize {
+		t.Errorf("Expected abra in the slot of kadabra, but got %s", config.save.Storage.Party[0].Name)
+	}
+
+	book, err := config.tradeBook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Offers) != 0 || len(book.Log) != 2 || book.Log[1].Action != "accepted" {
+		t.Errorf("Expected the offer and its acceptance in the log, but got %+v", book.Log)
+	}
+}
+
+// Two Pokedexes open on the same saves: a trade done in one isn't undone by the other
+func TestTradeWithAnotherPokedexOpen(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+	mistyConfig := newConfig(savefile.New(), savefile.TrainerPath(config.dir, "misty"), strings.NewReader(""), io.Discard, io.Discard)
+	mistyConfig.trainer = "misty"
+	mistyConfig.dir = config.dir
+	give := func(config *Config, name string) {
+		t.Helper()
+		pokemon, err := pokeapi.GetPokemon(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.save.Storage.Add(pc.FromAPI(pokemon))
+		err = config.persist()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	give(config, "pikachu")
+	give(mistyConfig, "staryu")
+
+	if err := mistyConfig.execute("trade offer staryu default pikachu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.execute("trade accept"); err != nil {
+		t.Fatal(err)
+	}
+
+	// What misty did in the meantime is lost, but not the trade
+	mistyConfig.save.Storage.Party[0].Nickname = "sparky"
+	if err := mistyConfig.persist(); !errors.Is(err, savefile.ErrChanged) {
+		t.Errorf("Expected the save of misty changed by the trade, but got %v", err)
+	}
+	if mistyConfig.save.Storage.Party[0].Name != "pikachu" {
+		t.Errorf("Expected misty to have pikachu, but got %s", mistyConfig.save.Storage.Party[0].Name)
+	}
+	// Commands start from the save as it is
+	give(config, "eevee")
+	if err := config.execute("trade offer eevee misty pikachu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mistyConfig.execute("trade accept"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.execute("nickname pikachu sparky"); err != nil {
+		t.Fatal(err)
+	}
+	save, err := savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if party := save.Storage.Party; len(party) != 2 || party[0].Name != "staryu" || party[1].Name != "pikachu" || party[1].Nickname != "sparky" {
+		t.Errorf("Expected staryu and pikachu called sparky, but got %v", party)
+	}
+}
+
+func TestParseBattleAction(t *testing.T) {
+	moves := battle.MovesFor([]string{"electric"})
+	cases := []struct {
+		input    string
+		expected battle.Action
+	}{
+		{"1", battle.Action{Kind: battle.UseMove, Index: 0}},
+		{"Tackle", battle.Action{Kind: battle.UseMove, Index: 1}},
+		{"switch 3", battle.Action{Kind: battle.Switch, Index: 2}},
+		{"run", battle.Action{Kind: battle.Forfeit}},
+	}
+	for _, c := range cases {
+		action, err := parseBattleAction(c.input, moves)
+		if err != nil || action != c.expected {
+			t.Errorf("Expected %+v for %q, but got %+v (%v)", c.expected, c.input, action, err)
+		}
+	}
+	for _, input := range []string{"", "splash", "switch pikachu"} {
+		if _, err := parseBattleAction(input, moves); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+
+	team, err := config.npcTeam("oreburgh-mine-1f", 4, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(team) != 4 {
+		t.Fatalf("Expected a team of 4, but got %+v", team)
+	}
+	for _, p := range team {
+		if (p.Name != "geodude" || p.Level < 5 || p.Level > 7) && (p.Name != "onix" || p.Level < 6 || p.Level > 8) {
+			t.Errorf("Expected the Pokemon of oreburgh-mine-1f at their levels, but got %s at level %d", p.Name, p.Level)
+		}
+	}
+
+	if err := config.execute("challenge oreburgh-mine-1f"); !errors.Is(err, errNobodyToAsk) {
+		t.Errorf("Expected an error without anybody to choose the moves, but got %v", err)
+	}
+	config.readLine = func(string) (string, error) { return "forfeit", nil }
+	if err := config.execute("challenge oreburgh-mine-1f"); !errors.Is(err, battle.ErrEmptyTeam) {
+		t.Errorf("Expected an error without a party, but got %v", err)
+	}
+
+	pokemon, err := pokeapi.GetPokemon("geodude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.save.Storage.Add(pc.FromAPI(pokemon))
+	if err := config.execute("challenge oreburgh-mine-1f --difficulty=impossible"); err == nil {
+		t.Errorf("Expected an error for an unknown difficulty")
+	}
+	config.args, _ = cmdline.Parse("challenge oreburgh-mine-1f --difficulty=hard --size=2")
+	result, err := commandChallenge(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := result.(battleResult); r.Won || r.Turns != 1 || r.Winner != r.Opponent {
+		t.Errorf("Expected to lose by forfeiting, but got %+v", r)
+	}
+}
+
+func TestGym(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+	config.gyms = []gym.Region{{
+		Name:     "sinnoh",
+		LevelCap: 15,
+		Gyms: []gym.Gym{
+			{Leader: "byron", Badge: "mine-badge", Type: "steel", Location: "canalave-city", LevelCap: 30, Team: []gym.Member{{Name: "geodude", Level: 5}}},
+			{Leader: "volkner", Badge: "beacon-badge", Type: "electric", Location: "sunyshore-city", LevelCap: 100, Team: []gym.Member{{Name: "octillery", Level: 50}}},
+		},
+	}}
+	config.readLine = func(string) (string, error) { return "psychic", nil }
+	pokemon, err := pokeapi.GetPokemon("alakazam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alakazam := pc.FromAPI(pokemon)
+	alakazam.Level = 50
+	config.save.Storage.Add(alakazam)
+
+	if err := config.execute("gym"); err == nil {
+		t.Errorf("Expected an error looking for a gym without exploring")
+	}
+	if _, err := config.explore("sunyshore-city-area"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.execute("gym challenge"); err == nil || !strings.Contains(err.Error(), "mine-badge") {
+		t.Errorf("Expected volkner to ask for the mine-badge first, but got %v", err)
+	}
+
+	if _, err := config.explore("canalave-city-area"); err != nil {
+		t.Fatal(err)
+	}
+	if team := battleTeam(config.save.Storage.Party, config.levelCap()); team[0].Level != 15 {
+		t.Errorf("Expected alakazam to fight at the level cap, but got level %d", team[0].Level)
+	}
+	// The badge is saved even if the battle can't be logged
+	logPath := savefile.EventLogPath(config.savePath)
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(logPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config.args, _ = cmdline.Parse("gym challenge")
+	result, err := commandGym(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := result.(gymBattleResult); !r.Won || r.Badge != "mine-badge" || r.LevelCap != 30 || r.Warning == "" {
+		t.Errorf("Expected to earn the mine-badge, with a warning about the stats, but got %+v", r)
+	}
+
+	saved, err := savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Badges) != 1 || saved.Badges[0].Leader != "byron" {
+		t.Errorf("Expected the badge in the save, but got %+v", saved.Badges)
+	}
+	config.args, _ = cmdline.Parse("badges")
+	result, _ = commandBadges(config)
+	if r := result.(badgesResult); r.LevelCap != 30 || r.Regions[0].Badges[0].Earned == nil || r.Regions[0].Badges[1].Earned != nil {
+		t.Errorf("Unexpected badges %+v", r)
+	}
+}
+
+func TestStats(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+	const balls = 1000
+	config.save.Inventory[savefile.PokeBall] = balls
+
+	if _, err := config.explore("canalave-city-area"); err != nil {
+		t.Fatal(err)
+	}
+	unlocked := []string{}
+	for _, name := range []string{"bulbasaur", "charmander", "squirtle"} {
+		for range 100 {
+			result, err := config.catch(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unlocked = append(unlocked, result.Achievements...)
+			if result.Caught {
+				break
+			}
+		}
+	}
+	// The grass type of the fixtures only has bulbasaur
+	if strings.Join(unlocked, ", ") != "Gotta catch 'em all, Type master, Professor Oak's table" {
+		t.Errorf("Expected the first catch, type master and kanto starters achievements, but got %v", unlocked)
+	}
+
+	config.args, _ = cmdline.Parse("stats")
+	result, err := commandStats(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := result.(statsResult)
+	if r.Caught != 3 || r.Attempts != balls-config.save.Inventory[savefile.PokeBall] || r.AreasExplored != 1 || len(r.BySpecies) != 3 || len(r.ByArea) != 0 {
+		t.Errorf("Unexpected stats %+v", r.Summary)
+	}
+
+	saved, err := savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := saved.AchievementIDs(); len(ids) != 3 || ids[2] != "kanto-starters" {
+		t.Errorf("Expected the achievements in the save, but got %v", ids)
+	}
+	if err := config.execute("stats everything"); err == nil {
+		t.Errorf("Expected an error for an unknown breakdown")
+	}
+}
+
+func TestStatsOfOlderSave(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+	// A save from before the event log
+	err := os.WriteFile(config.savePath, []byte(`{"version": 5, "storage": null, "pokedex": null,
+		"inventory": {"poke-ball": 15}, "stats": {"areas_explored": 2, "balls_thrown": 5, "caught": 3}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.save, err = savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.explore("canalave-city-area"); err != nil {
+		t.Fatal(err)
+	}
+	config.persist()
+
+	// Loading it again doesn't count them twice
+	config.save, err = savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.args, _ = cmdline.Parse("stats")
+	result, err := commandStats(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := result.(statsResult)
+	if s.AreasExplored != 3 || s.Attempts != 5 || s.Caught != 3 || s.Escapes != 2 || len(s.BySpecies) != 0 {
+		t.Errorf("Expected the stats of the save and the area explored, but got %+v", s.Summary)
+	}
+
+	config.args, _ = cmdline.Parse("trainer")
+	result, err = commandTrainer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trainer := result.(trainerResult); trainer.Stats != (savefile.Stats{AreasExplored: 3, BallsThrown: 5, Caught: 3}) {
+		t.Errorf("Expected the same stats in trainer, but got %+v", trainer.Stats)
+	}
+}
+
+func TestCatchSavedWhenStatsFail(t *testing.T) {
+	serveMockAPI(t)
+	config := newTestConfig(t)
+	// The event log can't be written
+	err := os.Mkdir(savefile.EventLogPath(config.savePath), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := config.catch("magikarp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Warning, "could not update the stats") || result.BallsLeft != savefile.StartingBalls-1 {
+		t.Errorf("Expected the throw with a warning about the stats, but got %+v", result)
+	}
+	saved, err := savefile.Load(config.savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Inventory[savefile.PokeBall] != savefile.StartingBalls-1 {
+		t.Errorf("Expected the Poke Ball thrown to be saved, but got %d left", saved.Inventory[savefile.PokeBall])
+	}
+	if caught := len(saved.Storage.All()) == 1; caught != result.Caught {
+		t.Errorf("Expected the save to agree with the result (caught %v)", result.Caught)
+	}
+}
+
+func TestSyncInvalidResource(t *testing.T) {
+	config := newTestConfig(t)
+	for _, line := range []string{"sync ../../etc", "sync pokemon/1", "sync ''"} {
+		err := config.execute(line)
+		if err == nil || !strings.Contains(err.Error(), "invalid resource") {
+			t.Errorf("%s: expected an invalid resource, but got %v", line, err)
+		}
+	}
+	if config.mirror != nil {
+		t.Errorf("Expected the mirror left alone")
+	}
+}
+
+func TestStatDistributionNeedsEveryPokemon(t *testing.T) {
+	config := newTestConfig(t)
+	dir := t.TempDir()
+	pikachu := `{"stats": [{"base_stat": 35, "stat": {"name": "hp"}}]}`
+	err := os.MkdirAll(filepath.Join(dir, "pokemon"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "pokemon", "pikachu.json"), []byte(pikachu), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := func(complete bool) {
+		t.Helper()
+		data, _ := json.Marshal(mirror.Manifest{Version: 1, Resources: map[string]*mirror.Resource{
+			"pokemon": {Complete: complete, Count: 1, Entries: map[string]mirror.Entry{"pikachu": {ID: 25}}},
+		}})
+		err := os.WriteFile(filepath.Join(dir, mirror.ManifestFile), data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.mirror, err = mirror.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A sync that stopped halfway
+	manifest(false)
+	if stats := config.statDistribution(); stats != nil {
+		t.Errorf("Expected no percentiles with some of the Pokemon, but got %v", stats)
+	}
+	manifest(true)
+	if stats := config.statDistribution(); len(stats["hp"]) != 1 {
+		t.Errorf("Expected the hp of pikachu, but got %v", stats)
+	}
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/names"
+	"github.com/neixir/pokedex/internal/pokeapi"
+)
+
+// nameIndex returns the index of names, kept next to the save file.
+func (config *Config) nameIndex() *names.Index {
+	if config.names == nil {
+		config.names = names.Open(filepath.Join(config.dir, "names.json"))
+	}
+	return config.names
+}
+
+// didYouMean adds the names closest to name to a "not found" error of PokeAPI.
+// kind is the list endpoint to look in, e.g. "pokemon".
+func (config *Config) didYouMean(err error, kind string, name string) error {
+	if !errors.Is(err, pokeapi.ErrNotFound) {
+		return err
+	}
+
+	suggestions := config.nameIndex().Suggest(kind, name)
+	if len(suggestions) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\ndid you mean %s?", err, strings.Join(suggestions, ", "))
+}
+
+// searchKinds are the names accepted by --kind.
+var searchKinds = map[string]string{
+	"pokemon":       "pokemon",
+	"area":          "location-area",
+	"location-area": "location-area",
+	"move":          "move",
+	"item":          "item",
+}
+
+type searchResult struct {
+	Query   string        `json:"query"`
+	Matches []names.Match `json:"matches"`
+	// Set when some lists could not be searched
+	Warning string `json:"warning,omitempty"`
+}
+
+func (r searchResult) Text(w io.Writer) {
+	if len(r.Matches) == 0 {
+		fmt.Fprintf(w, "Nothing found for %q\n", r.Query)
+	}
+	for _, m := range r.Matches {
+		fmt.Fprintf(w, "%-14s %s\n", m.Kind, m.Name)
+	}
+	if r.Warning != "" {
+		fmt.Fprintf(w, "(%s)\n", r.Warning)
+	}
+}
+
+func (r searchResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, m := range r.Matches {
+		rows = append(rows, []string{m.Kind, m.Name})
+	}
+	return []string{"kind", "name"}, rows
+}
+
+func commandSearch(config *Config) (any, error) {
+	flags := config.args.Flags
+	query := strings.Join(config.args.Positional, "-")
+
+	kinds := names.Kinds
+	if value, ok := flags["kind"]; ok {
+		kind, ok := searchKinds[value]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind %q, use pokemon, area, move or item", value)
+		}
+		kinds = []string{kind}
+	}
+
+	limit := 10
+	if value, ok := flags["limit"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid limit: %s", value)
+		}
+		limit = n
+	}
+
+	index := config.nameIndex()
+	if flags["refresh"] == "true" {
+		err := index.Forget(kinds...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	matches, err := index.Search(query, limit, kinds...)
+	result := searchResult{Query: query, Matches: matches}
+	if err != nil {
+		if len(matches) == 0 {
+			return nil, err
+		}
+		result.Warning = "could not search " + strings.ReplaceAll(err.Error(), "\n", "; ")
+	}
+	return result, nil
+}
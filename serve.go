@@ -0,0 +1,381 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neixir/pokedex/internal/cmdline"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+// runServe serves the Pokedex as a JSON API, so other tools can use it:
+//
+//	POST   /sessions         {"user": "ash"} -> {"user": "ash", "token": "...", "expires": "..."}
+//	DELETE /sessions         ends the session of the token
+//	GET    /areas            ?page=1&limit=20
+//	GET    /areas/{name}     explores an area
+//	GET    /pokemon/{name}   a Pokemon (or the nickname of a caught one)
+//	POST   /catch            {"pokemon": "pikachu"}
+//	GET    /pokedex          ?mode=caught|seen|completion&type=...&region=...
+//
+// Everything but POST /sessions needs the header "Authorization: Bearer <token>".
+// Users are trainer profiles (see "trainer"), so they play the same game in the REPL.
+//
+// There are no passwords: anybody who can reach the server can play as any
+// trainer. It's meant for trusted use, which is why it listens on localhost
+// unless told otherwise.
+func runServe(config *Config, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8000", "address to listen on")
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+
+	// Cache messages are noise in a server
+	pokeapi.DebugOutput = io.Discard
+
+	fmt.Fprintf(config.errOut, "Serving the Pokedex API on http://%s/\n", *addr)
+	err = http.ListenAndServe(*addr, newAPIServer(config))
+	if err != nil {
+		fmt.Fprintln(config.errOut, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// How long a session token lasts
+const sessionLifetime = 24 * time.Hour
+
+// session is the game of one user. Its requests are run one at a time.
+type session struct {
+	mu     sync.Mutex
+	config *Config
+}
+
+type sessionToken struct {
+	user    string
+	expires time.Time
+}
+
+type apiServer struct {
+	// The Config of "pokedex serve": sessions share its caches, mirror and name index
+	base *Config
+	mux  *http.ServeMux
+
+	mu     sync.Mutex
+	users  map[string]*session
+	tokens map[string]sessionToken
+}
+
+func newAPIServer(base *Config) *apiServer {
+	s := &apiServer{
+		base:   base,
+		mux:    http.NewServeMux(),
+		users:  map[string]*session{},
+		tokens: map[string]sessionToken{},
+	}
+
+	s.mux.HandleFunc("POST /sessions", s.handleLogin)
+	s.mux.HandleFunc("DELETE /sessions", s.handleLogout)
+	s.mux.HandleFunc("GET /areas", s.withSession(apiAreas))
+	s.mux.HandleFunc("GET /areas/{name}", s.withSession(apiExplore))
+	s.mux.HandleFunc("GET /pokemon/{name}", s.withSession(apiPokemon))
+	s.mux.HandleFunc("POST /catch", s.withSession(apiCatch))
+	s.mux.HandleFunc("GET /pokedex", s.withSession(apiPokedex))
+	return s
+}
+
+// The largest request body, far more than any request needs
+const maxBody = 64 << 10
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	s.mux.ServeHTTP(w, r)
+}
+
+// apiError is an error with the HTTP status to answer with.
+type apiError struct {
+	Status int
+	Err    error
+}
+
+func (e *apiError) Error() string {
+	return e.Err.Error()
+}
+
+func badRequest(format string, a ...any) error {
+	return &apiError{Status: http.StatusBadRequest, Err: fmt.Errorf(format, a...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.Status
+	case errors.As(err, new(*pageRangeError)), errors.Is(err, pokeapi.ErrNotFound), errors.Is(err, pc.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// decodeBody reads a JSON request body into v.
+func decodeBody(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		return &apiError{Status: http.StatusRequestEntityTooLarge, Err: fmt.Errorf("the body is over %d bytes", tooLarge.Limit)}
+	}
+	if err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+type sessionResult struct {
+	User    string    `json:"user"`
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s *apiServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		User string `json:"user"`
+	}
+	err := decodeBody(r, &body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	user := strings.ToLower(body.User)
+	if !savefile.ValidTrainer(user) {
+		writeError(w, &apiError{Status: http.StatusBadRequest, Err: savefile.ErrInvalidTrainer})
+		return
+	}
+
+	// Loading the save can take a while, and other requests don't need to wait for it
+	s.mu.Lock()
+	_, ok := s.users[user]
+	s.mu.Unlock()
+	var sess *session
+	if !ok {
+		savePath := savefile.TrainerPath(s.base.dir, user)
+		save, err := savefile.Load(savePath)
+		if err != nil {
+			writeError(w, fmt.Errorf("could not load the save of %s: %w", user, err))
+			return
+		}
+		sess = &session{config: s.newSessionConfig(user, save, savePath)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Somebody may have logged in as user meanwhile
+	if _, ok := s.users[user]; !ok {
+		s.users[user] = sess
+	}
+
+	// Tokens only expire when used, so the ones nobody uses again go now
+	now := time.Now()
+	for token, t := range s.tokens {
+		if now.After(t.expires) {
+			delete(s.tokens, token)
+		}
+	}
+	token := newToken()
+	expires := now.Add(sessionLifetime)
+	s.tokens[token] = sessionToken{user: user, expires: expires}
+	writeJSON(w, http.StatusCreated, sessionResult{User: user, Token: token, Expires: expires})
+}
+
+func (s *apiServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+
+	s.mu.Lock()
+	_, ok := s.tokens[token]
+	delete(s.tokens, token)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, &apiError{Status: http.StatusUnauthorized, Err: errors.New("invalid session token")})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSessionConfig makes the Config of a user. The caches, the mirror and
+// the name index are the ones of the server, so everybody benefits from them.
+func (s *apiServer) newSessionConfig(user string, save *savefile.Save, savePath string) *Config {
+	config := newConfig(save, savePath, strings.NewReader(""), io.Discard, io.Discard)
+	config.trainer = user
+	config.dir = s.base.dir
+	config.output = render.JSON
+	config.locationNamesCache = s.base.locationNamesCache
+	config.pokemonNamesCache = s.base.pokemonNamesCache
+	config.mirror = s.base.mirror
+	config.names = s.base.nameIndex()
+	return config
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// withSession finds the session of the request and runs handler with its Config.
+func (s *apiServer) withSession(handler func(*Config, *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		token := bearerToken(r)
+		if time.Now().After(s.tokens[token].expires) {
+			delete(s.tokens, token)
+		}
+		sess, ok := s.users[s.tokens[token].user]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, &apiError{Status: http.StatusUnauthorized, Err: errors.New("missing, invalid or expired session token, POST /sessions first")})
+			return
+		}
+
+		sess.mu.Lock()
+		// The REPL and trades change the save too, so every request starts from the file
+		save, err := savefile.Load(sess.config.savePath)
+		var result any
+		if err == nil {
+			sess.config.save = save
+			result, err = handler(sess.config, r)
+		}
+		sess.mu.Unlock()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// queryInt reads a positive number from the query string.
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, badRequest("invalid %s: %s", name, value)
+	}
+	return n, nil
+}
+
+// The most areas GET /areas returns at once
+const maxAreaLimit = 100
+
+func apiAreas(config *Config, r *http.Request) (any, error) {
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil {
+		return nil, err
+	}
+	if limit > maxAreaLimit {
+		return nil, badRequest("invalid limit: %d, the most is %d", limit, maxAreaLimit)
+	}
+	page, err := queryInt(r, "page", 1)
+	if err != nil {
+		return nil, err
+	}
+	if page-1 > math.MaxInt/limit {
+		return nil, badRequest("invalid page: %d", page)
+	}
+	// Every request says which page it wants, but showAreaPage still uses the
+	// count of the last one to refuse pages that don't exist
+	return showAreaPage(config, (page-1)*limit, limit)
+}
+
+func apiExplore(config *Config, r *http.Request) (any, error) {
+	return config.explore(strings.ToLower(r.PathValue("name")))
+}
+
+type pokemonResult struct {
+	inspectResult
+	ID     int  `json:"id"`
+	Caught bool `json:"caught"`
+}
+
+func apiPokemon(config *Config, r *http.Request) (any, error) {
+	pokemon, err := config.lookupPokemon(r.PathValue("name"))
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := config.save.Pokedex.Get(pokemon.Name)
+	caught := ok && entry.Caught
+	return pokemonResult{
+		inspectResult: newInspectResult(pc.FromAPI(pokemon)),
+		ID:            pokemon.ID,
+		Caught:        caught,
+	}, nil
+}
+
+func apiCatch(config *Config, r *http.Request) (any, error) {
+	var body struct {
+		Pokemon string `json:"pokemon"`
+	}
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Pokemon == "" {
+		return nil, badRequest("which pokemon? send {\"pokemon\": \"<name>\"}")
+	}
+
+	return config.catch(strings.ToLower(body.Pokemon))
+}
+
+var pokedexModes = map[string]bool{"caught": true, "seen": true, "completion": true}
+
+// apiPokedex runs the pokedex command, with the query string as its arguments.
+func apiPokedex(config *Config, r *http.Request) (any, error) {
+	query := r.URL.Query()
+	args := cmdline.Args{Command: "pokedex", Flags: map[string]string{}}
+	if mode := query.Get("mode"); mode != "" {
+		if !pokedexModes[mode] {
+			return nil, badRequest("unknown pokedex mode %q (use caught, seen or completion)", mode)
+		}
+		args.Positional = append(args.Positional, mode)
+	}
+	for _, name := range []string{"type", "region"} {
+		if value := query.Get(name); value != "" {
+			args.Flags[name] = value
+		}
+	}
+	config.args = args
+
+	return commandPokedex(config)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/neixir/pokedex/internal/mockapi"
+)
+
+// runServeMock serves the bundled PokeAPI fixtures, so the Pokedex can be run
+// against localhost: pokedex -api http://localhost:8080/api/v2/
+func runServeMock(args []string, log io.Writer) int {
+	flags := flag.NewFlagSet("serve-mock", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	fixturesDir := flags.String("fixtures", "", "directory with <resource>/<name>.json fixtures (default: the bundled ones)")
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+
+	var fixtures fs.FS = mockapi.Fixtures()
+	if *fixturesDir != "" {
+		fixtures = os.DirFS(*fixturesDir)
+	}
+
+	server, err := mockapi.New(fixtures)
+	if err != nil {
+		fmt.Fprintf(log, "could not load fixtures: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(log, "Serving %v on http://%s/api/v2/\n", server.Kinds(), *addr)
+	err = http.ListenAndServe(*addr, server)
+	if err != nil {
+		fmt.Fprintln(log, err)
+		return exitFailure
+	}
+	return exitOK
+}
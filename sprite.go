@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/sprite"
+)
+
+type spriteResult struct {
+	Pokemon string `json:"pokemon"`
+	URL     string `json:"url"`
+	Art     string `json:"art"`
+}
+
+func (r spriteResult) Text(w io.Writer) {
+	fmt.Fprint(w, r.Art)
+}
+
+func commandSprite(config *Config) (any, error) {
+	flags := config.args.Flags
+
+	pokemon, err := config.lookupPokemon(config.args.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := pokeapi.SpriteURL(pokemon, flags["generation"], config.args.Bool("back"), config.args.Bool("shiny"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sprites are kept next to the save file
+	cache := sprite.Cache{Dir: filepath.Join(config.dir, "sprites")}
+	data, err := cache.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	img, err := sprite.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := sprite.DetectMode()
+	if config.args.Bool("ascii") {
+		mode = sprite.ASCII
+	}
+	if config.args.Bool("color") {
+		mode = sprite.TrueColor
+	}
+
+	var art strings.Builder
+	sprite.Render(&art, img, mode)
+	return spriteResult{Pokemon: pokemon.Name, URL: url, Art: art.String()}, nil
+}
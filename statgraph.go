@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/typechart"
+)
+
+const (
+	// Highest value a base stat can have
+	maxStat = 255
+	// Width in characters of the bar of a 255
+	statBarWidth = 40
+)
+
+// statDistribution has, for every stat and for "total", the sorted values of
+// every Pokemon in the offline mirror.
+type statDistribution map[string][]int
+
+// statDistribution reads the stats of all the Pokemon of the mirror, the first
+// time it's needed. It is nil until the mirror has every Pokemon: the ones of a
+// sync that stopped halfway would give percentiles that mean nothing.
+func (config *Config) statDistribution() statDistribution {
+	if config.stats != nil || config.mirror == nil {
+		return config.stats
+	}
+	if !config.mirror.Stats()["pokemon"].Complete {
+		return nil
+	}
+
+	names := config.mirror.Names("pokemon")
+	if len(names) == 0 {
+		return nil
+	}
+
+	stats := statDistribution{}
+	for _, name := range names {
+		data, err := config.mirror.Read("pokemon", name)
+		if err != nil {
+			continue
+		}
+		// Only the stats: the whole Pokemon is much bigger
+		var pokemon struct {
+			Stats []struct {
+				BaseStat int `json:"base_stat"`
+				Stat     struct {
+					Name string `json:"name"`
+				} `json:"stat"`
+			} `json:"stats"`
+		}
+		if json.Unmarshal(data, &pokemon) != nil {
+			continue
+		}
+		total := 0
+		for _, s := range pokemon.Stats {
+			stats[s.Stat.Name] = append(stats[s.Stat.Name], s.BaseStat)
+			total += s.BaseStat
+		}
+		stats["total"] = append(stats["total"], total)
+	}
+	for _, values := range stats {
+		sort.Ints(values)
+	}
+
+	config.stats = stats
+	return stats
+}
+
+// percentile returns the share (0-100) of Pokemon with a lower value of stat.
+func (d statDistribution) percentile(stat string, value int) (int, bool) {
+	values := d[stat]
+	if len(values) == 0 {
+		return 0, false
+	}
+	lower := sort.SearchInts(values, value)
+	return lower * 100 / len(values), true
+}
+
+type statGraphResult struct {
+	inspectResult
+	Total int `json:"total"`
+	// Share of the Pokemon in the offline mirror with a lower value, for each stat and the total
+	Percentiles map[string]int `json:"percentiles,omitempty"`
+	colors      bool
+}
+
+func newStatGraphResult(config *Config, inspect inspectResult) statGraphResult {
+	result := statGraphResult{inspectResult: inspect, colors: render.Colors(config.out)}
+	for _, stat := range inspect.Stats {
+		result.Total += stat.Value
+	}
+
+	stats := config.statDistribution()
+	if stats == nil {
+		return result
+	}
+	result.Percentiles = map[string]int{}
+	for _, stat := range inspect.Stats {
+		if p, ok := stats.percentile(stat.Name, stat.Value); ok {
+			result.Percentiles[stat.Name] = p
+		}
+	}
+	if p, ok := stats.percentile("total", result.Total); ok {
+		result.Percentiles["total"] = p
+	}
+	return result
+}
+
+// statColor goes from red for the lowest stats to cyan for the highest.
+func statColor(value int) (uint8, uint8, uint8) {
+	switch {
+	case value < 30:
+		return 0xF3, 0x44, 0x44
+	case value < 60:
+		return 0xFF, 0x7F, 0x0F
+	case value < 90:
+		return 0xFF, 0xDD, 0x57
+	case value < 120:
+		return 0xA0, 0xE5, 0x15
+	case value < 150:
+		return 0x23, 0xCD, 0x5E
+	}
+	return 0x00, 0xC2, 0xB8
+}
+
+// statBar draws value as a bar. With colours it uses eighths of a block, so bars are more precise.
+func statBar(value int, colors bool) string {
+	value = min(value, maxStat)
+	if !colors {
+		return strings.Repeat("#", value*statBarWidth/maxStat)
+	}
+
+	eighths := value * statBarWidth * 8 / maxStat
+	bar := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		bar += string([]rune("▏▎▍▌▋▊▉")[rest-1])
+	}
+	r, g, b := statColor(value)
+	return render.Foreground(bar, r, g, b)
+}
+
+func (r statGraphResult) Text(w io.Writer) {
+	badges := []string{}
+	for _, typ := range r.Types {
+		if r.colors {
+			cr, cg, cb := typechart.Color(typ)
+			badges = append(badges, render.Badge(strings.ToUpper(typ), cr, cg, cb))
+		} else {
+			badges = append(badges, "["+typ+"]")
+		}
+	}
+	fmt.Fprintf(w, "%s  %s\n", r.Name, strings.Join(badges, " "))
+	fmt.Fprintf(w, "Height: %.1f m  Weight: %.1f kg\n\n", float64(r.Height)/10, float64(r.Weight)/10)
+
+	line := func(name string, value int, bar string, padding int) {
+		text := fmt.Sprintf("%-16s %3d %s", name, value, bar)
+		if p, ok := r.Percentiles[name]; ok {
+			text += fmt.Sprintf("%s higher than %d%%", strings.Repeat(" ", padding), p)
+		}
+		fmt.Fprintln(w, text)
+	}
+	for _, stat := range r.Stats {
+		// The bar is padded to its full width, so the percentiles line up
+		cells := min(stat.Value, maxStat) * statBarWidth / maxStat
+		if r.colors && min(stat.Value, maxStat)*statBarWidth*8/maxStat%8 > 0 {
+			cells++
+		}
+		line(stat.Name, stat.Value, statBar(stat.Value, r.colors), statBarWidth-cells)
+	}
+	line("total", r.Total, "", statBarWidth)
+
+	if r.Percentiles == nil {
+		fmt.Fprintln(w, "\n(run \"sync pokemon\" to rank the stats against every Pokemon)")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/neixir/pokedex/internal/battle"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/savefile"
+	"github.com/neixir/pokedex/internal/stats"
+)
+
+// record adds events to the event log of the trainer.
+func (config *Config) record(events ...stats.Event) error {
+	now := time.Now()
+	for i := range events {
+		if events[i].At.IsZero() {
+			events[i].At = now
+		}
+	}
+	return stats.Append(savefile.EventLogPath(config.savePath), events...)
+}
+
+// recordBattle records how a battle ended. The battle counts even if that
+// fails, the result only gets a warning.
+func (config *Config) recordBattle(r battle.Result) battleResult {
+	result := battleResult{Result: r}
+	err := config.record(stats.Event{Kind: stats.Battle, Opponent: r.Opponent, Won: r.Won})
+	if err != nil {
+		result.Warning = fmt.Sprintf("could not update the stats: %v", err)
+	}
+	return result
+}
+
+// unlockAchievements checks the achievements after a Poke Ball is thrown,
+// caught being the Pokemon caught if it was, and returns the ones unlocked.
+func (config *Config) unlockAchievements(caught *pc.Pokemon) ([]stats.Achievement, error) {
+	events, err := stats.Read(savefile.EventLogPath(config.savePath))
+	if err != nil {
+		return nil, err
+	}
+	progress := stats.Progress{
+		Summary: stats.Summarize(events),
+		Caught: func(name string) bool {
+			e, ok := config.save.Pokedex.Get(name)
+			return ok && e.Caught
+		},
+	}
+	if caught != nil {
+		progress.CompletedTypes = config.completedTypes(caught.TypeNames(), progress.Caught)
+	}
+
+	unlocked := stats.Unlocked(progress, config.save.AchievementIDs())
+	for _, a := range unlocked {
+		config.save.Achievements = append(config.save.Achievements, savefile.Achievement{ID: a.ID, At: time.Now()})
+	}
+	return unlocked, nil
+}
+
+// completedTypes are the types with all of their Pokemon caught. Alternate
+// forms don't count, and a type PokeAPI can't tell us about now will be
+// checked again with the next catch.
+func (config *Config) completedTypes(types []string, caught func(string) bool) []string {
+	completed := []string{}
+	for _, t := range types {
+		typeInfo, err := pokeapi.GetType(t, config.pokemonNamesCache)
+		if err != nil {
+			continue
+		}
+		total, missing := 0, false
+		for _, p := range typeInfo.Pokemon {
+			// Forms have ids from 10001
+			if pokeapi.IDFromURL(p.Pokemon.URL) > 10000 {
+				continue
+			}
+			total++
+			if !caught(p.Pokemon.Name) {
+				missing = true
+				break
+			}
+		}
+		if total > 0 && !missing {
+			completed = append(completed, t)
+		}
+	}
+	return completed
+}
+
+type achievementLine struct {
+	stats.Achievement
+	// When it was unlocked, nil if it wasn't
+	Unlocked *time.Time `json:"unlocked,omitempty"`
+}
+
+type statsResult struct {
+	stats.Summary
+	Achievements []achievementLine `json:"achievements"`
+	// Only this breakdown of the catch rates: ball, species or area
+	Only string `json:"-"`
+}
+
+// breakdown is the catch rates by one of ball, species or area.
+type breakdown struct {
+	by    string
+	rates []stats.Rate
+}
+
+func (r statsResult) breakdowns() []breakdown {
+	all := []breakdown{{"ball", r.ByBall}, {"species", r.BySpecies}, {"area", r.ByArea}}
+	if r.Only == "" {
+		return all
+	}
+	for _, b := range all {
+		if b.by == r.Only {
+			return []breakdown{b}
+		}
+	}
+	return nil
+}
+
+func (r statsResult) Text(w io.Writer) {
+	if r.Only == "" {
+		fmt.Fprintf(w, "Poke Balls thrown: %d, caught %d, escaped %d (%d%% catch rate)\n", r.Attempts, r.Caught, r.Escapes, r.Percent())
+		fmt.Fprintf(w, "Areas explored: %d\n", r.AreasExplored)
+		fmt.Fprintf(w, "Battles: %d, won %d\n", r.Battles, r.BattlesWon)
+	}
+
+	for _, b := range r.breakdowns() {
+		fmt.Fprintf(w, "Catch rate by %s:\n", b.by)
+		if len(b.rates) == 0 {
+			fmt.Fprintln(w, "  (no Poke Balls thrown yet)")
+		}
+		for _, rate := range b.rates {
+			fmt.Fprintf(w, "  %-20s %3d/%-3d %3d%%\n", rate.Name, rate.Caught, rate.Attempts, rate.Percent())
+		}
+	}
+
+	if r.Only == "" {
+		unlocked := 0
+		for _, a := range r.Achievements {
+			if a.Unlocked != nil {
+				unlocked++
+			}
+		}
+		fmt.Fprintf(w, "Achievements: %d/%d\n", unlocked, len(r.Achievements))
+		for _, a := range r.Achievements {
+			mark := " "
+			if a.Unlocked != nil {
+				mark = "x"
+			}
+			fmt.Fprintf(w, "  [%s] %s: %s\n", mark, a.Name, a.Description)
+		}
+	}
+}
+
+func (r statsResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, b := range r.breakdowns() {
+		for _, rate := range b.rates {
+			rows = append(rows, []string{b.by, rate.Name, fmt.Sprint(rate.Attempts), fmt.Sprint(rate.Caught), fmt.Sprint(rate.Percent())})
+		}
+	}
+	return []string{"by", "name", "attempts", "caught", "rate"}, rows
+}
+
+func commandStats(config *Config) (any, error) {
+	only := strings.ToLower(config.args.Arg(0))
+	if only != "" && only != "ball" && only != "species" && only != "area" {
+		return nil, fmt.Errorf("unknown breakdown %q (use ball, species or area)", only)
+	}
+
+	events, err := stats.Read(savefile.EventLogPath(config.savePath))
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked := map[string]time.Time{}
+	for _, a := range config.save.Achievements {
+		unlocked[a.ID] = a.At
+	}
+	result := statsResult{Summary: stats.Summarize(events), Achievements: []achievementLine{}, Only: only}
+	for _, a := range stats.Achievements {
+		line := achievementLine{Achievement: a}
+		if at, ok := unlocked[a.ID]; ok {
+			line.Unlocked = &at
+		}
+		result.Achievements = append(result.Achievements, line)
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/mirror"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/savefile"
+)
+
+// openMirror opens the offline mirror of the save directory and makes pokeapi use it.
+func openMirror() (*mirror.Store, error) {
+	store, err := mirror.Open(filepath.Join(savefile.Dir(), "mirror"))
+	if err != nil {
+		return nil, err
+	}
+	pokeapi.Mirror = store
+	return store, nil
+}
+
+type syncResult struct {
+	Problems  []string        `json:"problems,omitempty"`
+	Resources []mirror.Report `json:"resources"`
+}
+
+func (r syncResult) Text(w io.Writer) {
+	for _, problem := range r.Problems {
+		fmt.Fprintf(w, "damaged: %s\n", problem)
+	}
+	for _, report := range r.Resources {
+		fmt.Fprintf(w, "%s: %d downloaded, %d already there, %d failed (%d total)\n",
+			report.Resource, report.Downloaded, report.Skipped, report.Failed, report.Total)
+		for _, e := range report.Errors {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+}
+
+func (r syncResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, report := range r.Resources {
+		rows = append(rows, []string{
+			report.Resource,
+			fmt.Sprint(report.Total),
+			fmt.Sprint(report.Downloaded),
+			fmt.Sprint(report.Skipped),
+			fmt.Sprint(report.Failed),
+		})
+	}
+	return []string{"resource", "total", "downloaded", "skipped", "failed"}, rows
+}
+
+// Names of PokeAPI resources, like pokemon-species
+var validResource = regexp.MustCompile(`^[a-z-]+$`)
+
+func commandSync(config *Config) (any, error) {
+	flags := config.args.Flags
+
+	resources := mirror.DefaultResources
+	if len(config.args.Positional) > 0 {
+		resources = []string{}
+		for _, resource := range config.args.Positional {
+			resource = strings.ToLower(resource)
+			// It becomes a directory of the mirror and a path of the API
+			if !validResource.MatchString(resource) {
+				return nil, fmt.Errorf("invalid resource %q (e.g. %s)", resource, strings.Join(mirror.DefaultResources, ", "))
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	jobs := 8
+	if value, ok := flags["jobs"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid number of jobs: %s", value)
+		}
+		jobs = n
+	}
+
+	if config.mirror == nil {
+		store, err := openMirror()
+		if err != nil {
+			return nil, err
+		}
+		config.mirror = store
+	}
+
+	result := syncResult{}
+	if flags["verify"] == "true" {
+		problems, err := config.mirror.Verify()
+		if err != nil {
+			return nil, err
+		}
+		result.Problems = problems
+	}
+
+	reports, err := config.mirror.Sync(resources, mirror.Options{
+		Jobs: jobs,
+		Progress: func(resource string, done, total int) {
+			fmt.Fprintf(config.errOut, "\r%s %d/%d", resource, done, total)
+			if done == total {
+				fmt.Fprintln(config.errOut)
+			}
+		},
+	})
+	result.Resources = reports
+	// The stats are read again with the Pokemon just synced
+	config.stats = nil
+	return result, err
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/savefile"
+	"github.com/neixir/pokedex/internal/trade"
+)
+
+// tradeBook opens the offers and the log of trades, kept with the saves.
+func (config *Config) tradeBook() (*trade.Book, error) {
+	book, err := trade.Open(filepath.Join(config.dir, "trades.json"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the trades: %w", err)
+	}
+	return book, nil
+}
+
+type offerLine struct {
+	ID      int    `json:"id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Offered string `json:"offered"`
+	Wanted  string `json:"wanted"`
+}
+
+func (l offerLine) String() string {
+	return fmt.Sprintf("#%d %s offers %s for %s's %s", l.ID, l.From, l.Offered, l.To, l.Wanted)
+}
+
+type tradeListResult struct {
+	Offers []offerLine `json:"offers"`
+}
+
+func (r tradeListResult) Text(w io.Writer) {
+	if len(r.Offers) == 0 {
+		fmt.Fprintln(w, "No pending trades.")
+	}
+	for _, offer := range r.Offers {
+		fmt.Fprintln(w, offer)
+	}
+}
+
+func (r tradeListResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, o := range r.Offers {
+		rows = append(rows, []string{fmt.Sprint(o.ID), o.From, o.Offered, o.To, o.Wanted})
+	}
+	return []string{"id", "from", "offered", "to", "wanted"}, rows
+}
+
+type tradeLogResult struct {
+	Log []trade.Event `json:"log"`
+}
+
+func (r tradeLogResult) Text(w io.Writer) {
+	if len(r.Log) == 0 {
+		fmt.Fprintln(w, "There have been no trades yet.")
+	}
+	for _, e := range r.Log {
+		fmt.Fprintf(w, "%s #%d %s: %s's %s for %s's %s\n",
+			e.At.Format("2006-01-02 15:04"), e.Offer, e.Action, e.From, e.Offered, e.To, e.Wanted)
+		for _, note := range e.Notes {
+			fmt.Fprintf(w, "  %s\n", note)
+		}
+	}
+}
+
+func (r tradeLogResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, e := range r.Log {
+		rows = append(rows, []string{e.At.Format("2006-01-02 15:04"), fmt.Sprint(e.Offer), e.Action, e.From, e.Offered, e.To, e.Wanted, strings.Join(e.Notes, "; ")})
+	}
+	return []string{"at", "id", "action", "from", "offered", "to", "wanted", "notes"}, rows
+}
+
+type tradeResult struct {
+	ID      int    `json:"id"`
+	Partner string `json:"partner"`
+	Gave    string `json:"gave"`
+	Got     string `json:"got"`
+	// e.g. "kadabra evolved into alakazam"
+	Evolutions []string `json:"evolutions,omitempty"`
+}
+
+func (r tradeResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "You traded your %s to %s for %s.\n", r.Gave, r.Partner, r.Got)
+	for _, evolution := range r.Evolutions {
+		fmt.Fprintf(w, "What? %s!\n", evolution)
+	}
+}
+
+func commandTrade(config *Config) (any, error) {
+	action := strings.ToLower(config.args.Arg(0))
+	// Another Pokedex may be trading with the same saves
+	if action == "offer" || action == "accept" || action == "decline" {
+		unlock, err := savefile.Lock(config.dir)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+	book, err := config.tradeBook()
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "":
+		result := tradeListResult{Offers: []offerLine{}}
+		for _, offer := range book.Pending(config.trainer) {
+			result.Offers = append(result.Offers, newOfferLine(offer))
+		}
+		return result, nil
+	case "log":
+		return tradeLogResult{Log: book.Log}, nil
+	case "offer":
+		if len(config.args.Positional) != 4 {
+			return nil, fmt.Errorf("usage: trade offer <your pokemon> <trainer> <their pokemon>")
+		}
+		return offerTrade(config, book, config.args.Arg(1), strings.ToLower(config.args.Arg(2)), config.args.Arg(3))
+	case "accept", "decline":
+		offer, err := pickOffer(config, book, action)
+		if err != nil {
+			return nil, err
+		}
+		if action == "accept" {
+			return acceptTrade(config, book, offer)
+		}
+		return declineTrade(config, book, offer)
+	}
+
+	return nil, fmt.Errorf("unknown trade action %q (use offer, accept, decline or log)", action)
+}
+
+func newOfferLine(offer trade.Offer) offerLine {
+	return offerLine{
+		ID:      offer.ID,
+		From:    offer.From,
+		To:      offer.To,
+		Offered: offer.Offered.DisplayName(),
+		Wanted:  offer.Wanted.DisplayName(),
+	}
+}
+
+// partyPokemon finds a Pokemon to trade. Only Pokemon in the party can be traded, as in the games.
+func partyPokemon(storage *pc.Storage, ref string, owner string) (pc.Pokemon, error) {
+	if i, ok := storage.FindParty(ref); ok {
+		return storage.Party[i], nil
+	}
+	if _, _, ok := storage.FindBox(ref); ok {
+		return pc.Pokemon{}, fmt.Errorf("%s is in the PC of %s, only Pokemon in the party can be traded", ref, owner)
+	}
+	return pc.Pokemon{}, fmt.Errorf("%s has no %s", owner, ref)
+}
+
+func offerTrade(config *Config, book *trade.Book, mine string, trainer string, theirs string) (any, error) {
+	if trainer == config.trainer {
+		return nil, fmt.Errorf("you can't trade with yourself")
+	}
+	if !savefile.ValidTrainer(trainer) || !savefile.TrainerExists(config.dir, trainer) {
+		return nil, fmt.Errorf("there is no trainer called %s, see trainer list", trainer)
+	}
+
+	offered, err := partyPokemon(config.save.Storage, mine, "you")
+	if err != nil {
+		return nil, err
+	}
+	other, err := savefile.Load(savefile.TrainerPath(config.dir, trainer))
+	if err != nil {
+		return nil, fmt.Errorf("could not load trainer %s: %w", trainer, err)
+	}
+	wanted, err := partyPokemon(other.Storage, theirs, trainer)
+	if err != nil {
+		return nil, err
+	}
+
+	offer := book.Offer(config.trainer, trainer, offered, wanted)
+	err = book.Save()
+	if err != nil {
+		return nil, fmt.Errorf("could not save the offer: %w", err)
+	}
+	return render.Messagef("You offered your %s to %s for their %s (trade #%d).\n%s can answer with trade accept or trade decline.",
+		offered.DisplayName(), trainer, wanted.DisplayName(), offer.ID, trainer), nil
+}
+
+// pickOffer returns the offer given as argument. Without one, the only offer there is.
+// Offers can only be accepted by who they're made to, but can be declined by both sides.
+func pickOffer(config *Config, book *trade.Book, action string) (trade.Offer, error) {
+	candidates := []trade.Offer{}
+	for _, offer := range book.Pending(config.trainer) {
+		if offer.To == config.trainer || action == "decline" {
+			candidates = append(candidates, offer)
+		}
+	}
+
+	if len(config.args.Positional) < 2 {
+		if len(candidates) == 0 {
+			return trade.Offer{}, fmt.Errorf("there are no trades for you to %s", action)
+		}
+		if len(candidates) > 1 {
+			return trade.Offer{}, fmt.Errorf("there are %d trades, which one? (see trade)", len(candidates))
+		}
+		return candidates[0], nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(config.args.Arg(1), "#"))
+	if err != nil {
+		return trade.Offer{}, fmt.Errorf("invalid trade: %s", config.args.Arg(1))
+	}
+	for _, offer := range candidates {
+		if offer.ID == id {
+			return offer, nil
+		}
+	}
+	return trade.Offer{}, fmt.Errorf("there is no trade #%d for you to %s", id, action)
+}
+
+func declineTrade(config *Config, book *trade.Book, offer trade.Offer) (any, error) {
+	action := trade.Declined
+	if offer.From == config.trainer {
+		action = trade.Cancelled
+	}
+	book.Close(offer.ID, action)
+	err := book.Save()
+	if err != nil {
+		return nil, err
+	}
+	return render.Messagef("Trade #%d %s.", offer.ID, action), nil
+}
+
+// acceptTrade swaps the Pokemon of an offer. Both saves are written at once, so
+// either both trainers get their new Pokemon or nothing changes.
+func acceptTrade(config *Config, book *trade.Book, offer trade.Offer) (any, error) {
+	otherPath := savefile.TrainerPath(config.dir, offer.From)
+	other, err := savefile.Load(otherPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load trainer %s: %w", offer.From, err)
+	}
+
+	// The Pokemon may have been released, deposited or traded since the offer
+	theirs, ok := other.Storage.PartyIndex(offer.Offered)
+	reason := fmt.Sprintf("%s is no longer in the party of %s", offer.Offered.DisplayName(), offer.From)
+	mine, ok2 := config.save.Storage.PartyIndex(offer.Wanted)
+	if !ok2 {
+		reason = fmt.Sprintf("%s is no longer in your party", offer.Wanted.DisplayName())
+	}
+	if !ok || !ok2 {
+		book.Close(offer.ID, trade.Rejected, reason)
+		return nil, errors.Join(fmt.Errorf("trade #%d can't be done: %s", offer.ID, reason), book.Save())
+	}
+
+	given := config.save.Storage.Party[mine]
+	received := other.Storage.Party[theirs]
+	result := tradeResult{ID: offer.ID, Partner: offer.From, Gave: given.DisplayName(), Got: received.DisplayName()}
+
+	// Some Pokemon evolve when traded
+	notes := []string{}
+	received, note, err := config.tradeEvolution(received, given)
+	if err != nil {
+		return nil, err
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+	given, note, err = config.tradeEvolution(given, other.Storage.Party[theirs])
+	if err != nil {
+		return nil, err
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+
+	config.save.Storage.Party[mine] = received
+	config.save.Pokedex.MarkCaught(received.Name, received.ID, received.Species, received.TypeNames())
+	other.Storage.Party[theirs] = given
+	other.Pokedex.MarkCaught(given.Name, given.ID, given.Species, given.TypeNames())
+
+	// Once WriteAll has committed the trade, loading the saves finishes it
+	// If our save changed since it was loaded, WriteAll refuses to overwrite it
+	err = savefile.WriteAll(map[string]*savefile.Save{config.savePath: config.save, otherPath: other})
+	if err != nil && !errors.Is(err, savefile.ErrUnfinished) {
+		// Nothing was written: back to the party we had before the trade
+		if save, loadErr := savefile.Load(config.savePath); loadErr == nil {
+			config.save = save
+		}
+		return nil, fmt.Errorf("could not save the trade: %w", err)
+	}
+
+	book.Close(offer.ID, trade.Accepted, notes...)
+	err = book.Save()
+	if err != nil {
+		return nil, fmt.Errorf("the trade was done, but could not be logged: %w", err)
+	}
+
+	result.Evolutions = notes
+	return result, nil
+}
+
+// tradeEvolution evolves p if its species evolves by trading, for the Pokemon it's traded for.
+// It returns p as it ends up and a note saying what happened, empty if nothing.
+func (config *Config) tradeEvolution(p pc.Pokemon, tradedFor pc.Pokemon) (pc.Pokemon, string, error) {
+	species := p.Species
+	if species == "" {
+		species = p.Name
+	}
+	otherSpecies := tradedFor.Species
+	if otherSpecies == "" {
+		otherSpecies = tradedFor.Name
+	}
+
+	info, err := pokeapi.GetSpecies(species, config.pokemonNamesCache)
+	if err != nil {
+		return p, "", fmt.Errorf("could not check the evolutions of %s: %w", species, err)
+	}
+	chain, err := pokeapi.GetEvolutionChain(info.EvolutionChain.URL, config.pokemonNamesCache)
+	if err != nil {
+		return p, "", fmt.Errorf("could not check the evolutions of %s: %w", species, err)
+	}
+	link, ok := chain.Chain.Find(species)
+	if !ok {
+		return p, "", nil
+	}
+
+	for _, next := range link.EvolvesTo {
+		for _, d := range next.EvolutionDetails {
+			// We have no held items, so the trades that need one never evolve
+			if d.Trigger.Name != "trade" || d.HeldItem != nil {
+				continue
+			}
+			if d.TradeSpecies != nil && d.TradeSpecies.Name != otherSpecies {
+				continue
+			}
+
+			evolved, err := pokeapi.GetPokemon(next.Species.Name)
+			if err != nil {
+				return p, "", fmt.Errorf("could not evolve %s: %w", p.Name, err)
+			}
+			e := pc.FromAPI(evolved)
+			e.Nickname = p.Nickname
+			e.CaughtAt = p.CaughtAt
+			e.Level = p.Level
+			return e, fmt.Sprintf("%s evolved into %s", p.DisplayName(), e.Name), nil
+		}
+	}
+	return p, "", nil
+}
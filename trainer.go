@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/render"
+	"github.com/neixir/pokedex/internal/savefile"
+	"github.com/neixir/pokedex/internal/stats"
+)
+
+type trainerResult struct {
+	Name      string         `json:"name"`
+	Location  string         `json:"location,omitempty"`
+	Inventory map[string]int `json:"inventory"`
+	Pokemon   int            `json:"pokemon"`
+	Stats     savefile.Stats `json:"stats"`
+}
+
+func newTrainerResult(name string, save *savefile.Save, savePath string) (trainerResult, error) {
+	events, err := stats.Read(savefile.EventLogPath(savePath))
+	if err != nil {
+		return trainerResult{}, err
+	}
+	s := stats.Summarize(events)
+	return trainerResult{
+		Name:      name,
+		Location:  save.Location,
+		Inventory: save.Inventory,
+		Pokemon:   len(save.Storage.All()),
+		Stats:     savefile.Stats{AreasExplored: s.AreasExplored, BallsThrown: s.Attempts, Caught: s.Caught},
+	}, nil
+}
+
+func (r trainerResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Trainer %s\n", r.Name)
+	if r.Location != "" {
+		fmt.Fprintf(w, "Location: %s\n", r.Location)
+	}
+	fmt.Fprintf(w, "Pokemon: %d\n", r.Pokemon)
+
+	fmt.Fprintln(w, "Bag:")
+	items := []string{}
+	for item, n := range r.Inventory {
+		if n > 0 {
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	if len(items) == 0 {
+		fmt.Fprintln(w, "  (empty)")
+	}
+	for _, item := range items {
+		fmt.Fprintf(w, "  -%s x%d\n", item, r.Inventory[item])
+	}
+
+	fmt.Fprintf(w, "Explored %d areas, threw %d Poke Balls and caught %d Pokemon.\n",
+		r.Stats.AreasExplored, r.Stats.BallsThrown, r.Stats.Caught)
+}
+
+func (r trainerResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for item, n := range r.Inventory {
+		rows = append(rows, []string{item, fmt.Sprint(n)})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+	return []string{"item", "count"}, rows
+}
+
+type trainerLine struct {
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Pokemon  int    `json:"pokemon"`
+	Location string `json:"location,omitempty"`
+}
+
+type trainerListResult struct {
+	Trainers []trainerLine `json:"trainers"`
+}
+
+func (r trainerListResult) Text(w io.Writer) {
+	for _, t := range r.Trainers {
+		marker := " "
+		if t.Active {
+			marker = "*"
+		}
+		location := ""
+		if t.Location != "" {
+			location = ", in " + t.Location
+		}
+		fmt.Fprintf(w, "%s %s (%d Pokemon%s)\n", marker, t.Name, t.Pokemon, location)
+	}
+}
+
+func (r trainerListResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, t := range r.Trainers {
+		rows = append(rows, []string{t.Name, fmt.Sprint(t.Active), fmt.Sprint(t.Pokemon), t.Location})
+	}
+	return []string{"trainer", "active", "pokemon", "location"}, rows
+}
+
+// Cada entrenador te el seu fitxer: save.json el de sempre, trainers/<nom>.json els altres
+func commandTrainer(config *Config) (any, error) {
+	if len(config.args.Positional) == 0 {
+		result, err := newTrainerResult(config.trainer, config.save, config.savePath)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	action := strings.ToLower(config.args.Arg(0))
+	name := strings.ToLower(config.args.Arg(1))
+	if action != "list" && name == "" {
+		return nil, fmt.Errorf("which trainer?\nusage: trainer %s <name>", action)
+	}
+
+	switch action {
+	case "list":
+		return listTrainers(config)
+	case "new":
+		return newTrainer(config, name)
+	case "switch":
+		return switchTrainer(config, name)
+	}
+	return nil, fmt.Errorf("unknown trainer action %q (use new, switch or list)", action)
+}
+
+func listTrainers(config *Config) (any, error) {
+	names, err := savefile.Trainers(config.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := trainerListResult{Trainers: []trainerLine{}}
+	for _, name := range names {
+		save := config.save
+		if name != config.trainer {
+			save, err = savefile.Load(savefile.TrainerPath(config.dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("could not load trainer %s: %w", name, err)
+			}
+		}
+		result.Trainers = append(result.Trainers, trainerLine{
+			Name:     name,
+			Active:   name == config.trainer,
+			Pokemon:  len(save.Storage.All()),
+			Location: save.Location,
+		})
+	}
+	return result, nil
+}
+
+func newTrainer(config *Config, name string) (any, error) {
+	if !savefile.ValidTrainer(name) {
+		return nil, savefile.ErrInvalidTrainer
+	}
+	if savefile.TrainerExists(config.dir, name) {
+		return nil, fmt.Errorf("there is already a trainer called %s", name)
+	}
+
+	save := savefile.New()
+	save.Trainer = name
+	err := save.Write(savefile.TrainerPath(config.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("could not create trainer %s: %w", name, err)
+	}
+
+	_, err = switchTrainer(config, name)
+	if err != nil {
+		return nil, err
+	}
+	return render.Messagef("Welcome, %s! Here are %d Poke Balls to start your journey.", name, savefile.StartingBalls), nil
+}
+
+// switchTrainer saves the game of the current trainer and loads the one of name.
+func switchTrainer(config *Config, name string) (any, error) {
+	if !savefile.ValidTrainer(name) || !savefile.TrainerExists(config.dir, name) {
+		return nil, fmt.Errorf("there is no trainer called %s, see trainer list", name)
+	}
+
+	err := config.persist()
+	if err != nil {
+		return nil, err
+	}
+	savePath := savefile.TrainerPath(config.dir, name)
+	save, err := savefile.Load(savePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load trainer %s: %w", name, err)
+	}
+
+	config.save = save
+	config.savePath = savePath
+	config.trainer = name
+	config.lastExplored = nil
+	err = savefile.SetActiveTrainer(config.dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return render.Messagef("You are now playing as %s.", name), nil
+}
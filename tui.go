@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/neixir/pokedex/internal/lineedit"
+	"github.com/neixir/pokedex/internal/pc"
+	"github.com/neixir/pokedex/internal/pokeapi"
+	"github.com/neixir/pokedex/internal/sprite"
+	"github.com/neixir/pokedex/internal/tui"
+)
+
+// Panes that can have the focus. The detail pane shows what was opened in the others.
+type tuiPane int
+
+const (
+	paneAreas tuiPane = iota
+	paneEncounters
+	paneCollection
+	paneCount
+)
+
+const tuiHelp = "↑↓ move  ⇥ pane  ⏎ open  c catch  n/p page  q quit"
+
+// tuiModel is the state of "pokedex tui". handle changes it with each key and
+// view draws it, so it can be tested without a terminal.
+type tuiModel struct {
+	config *Config
+	focus  tuiPane
+
+	areas      areaPage
+	areaCursor int
+
+	area            string
+	encounters      []string
+	encounterCursor int
+
+	collectionCursor int
+
+	detail *pokeapi.PokemonType
+	sprite []string
+
+	status string
+	quit   bool
+	// Called before something slow (a download), to show it
+	loading func(what string)
+}
+
+func newTUIModel(config *Config) *tuiModel {
+	return &tuiModel{config: config, loading: func(string) {}}
+}
+
+// runTUI is "pokedex tui": a full-screen browser of areas, encounters and your collection.
+func runTUI(config *Config) int {
+	terminal, err := tui.Open()
+	if err != nil {
+		fmt.Fprintln(config.errOut, err)
+		return exitFailure
+	}
+	defer terminal.Close()
+	// The cache messages would be written over the screen
+	pokeapi.DebugOutput = io.Discard
+
+	m := newTUIModel(config)
+	m.loading = func(what string) {
+		m.status = "Loading " + what + "..."
+		terminal.Draw(m.view(terminal.Size()))
+	}
+	m.showPage(0)
+
+	for !m.quit {
+		terminal.Draw(m.view(terminal.Size()))
+		key, err := terminal.ReadKey()
+		if err != nil {
+			break
+		}
+		m.handle(key)
+	}
+	return exitOK
+}
+
+// showPage shows the location areas of a page (0-based).
+func (m *tuiModel) showPage(page int) {
+	limit := m.config.areaLimit
+	m.loading("areas")
+	result, err := showAreaPage(m.config, page*limit, limit)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.areas = result.(areaPage)
+	m.areaCursor = 0
+	m.status = ""
+}
+
+func (m *tuiModel) handle(key rune) {
+	switch key {
+	case 'q', lineedit.KeyEscape, lineedit.KeyCtrlC:
+		m.quit = true
+	case lineedit.KeyTab, lineedit.KeyRight:
+		m.focus = (m.focus + 1) % paneCount
+	case lineedit.KeyLeft:
+		m.focus = (m.focus + paneCount - 1) % paneCount
+	case lineedit.KeyUp, 'k':
+		m.move(-1)
+	case lineedit.KeyDown, 'j':
+		m.move(1)
+	case 'n':
+		if m.areas.Page < m.areas.Pages {
+			m.showPage(m.areas.Page)
+		}
+	case 'p':
+		if m.areas.Page > 1 {
+			m.showPage(m.areas.Page - 2)
+		}
+	case lineedit.KeyEnter:
+		m.open()
+	case 'c':
+		m.catch()
+	}
+}
+
+func (m *tuiModel) move(delta int) {
+	clamp := func(cursor int, n int) int {
+		return max(0, min(cursor+delta, n-1))
+	}
+	switch m.focus {
+	case paneAreas:
+		m.areaCursor = clamp(m.areaCursor, len(m.areas.Areas))
+	case paneEncounters:
+		m.encounterCursor = clamp(m.encounterCursor, len(m.encounters))
+	case paneCollection:
+		m.collectionCursor = clamp(m.collectionCursor, len(m.config.save.Storage.All()))
+	}
+}
+
+// open explores the selected area, or shows the selected Pokemon.
+func (m *tuiModel) open() {
+	switch m.focus {
+	case paneAreas:
+		if len(m.areas.Areas) == 0 {
+			return
+		}
+		area := m.areas.Areas[m.areaCursor]
+		m.loading(area)
+		result, err := m.config.explore(area)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		m.area = area
+		m.encounters = result.Pokemon
+		m.encounterCursor = 0
+		m.focus = paneEncounters
+		m.status = fmt.Sprintf("%d Pokemon found in %s", len(m.encounters), area)
+	case paneEncounters:
+		if len(m.encounters) > 0 {
+			m.showPokemon(m.encounters[m.encounterCursor])
+		}
+	case paneCollection:
+		all := m.config.save.Storage.All()
+		if len(all) > 0 {
+			m.showPokemon(all[m.collectionCursor].Name)
+		}
+	}
+}
+
+func (m *tuiModel) showPokemon(name string) {
+	m.loading(name)
+	pokemon, err := pokeapi.GetPokemon(name)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.detail = &pokemon
+	m.status = ""
+
+	// The sprite is drawn in ASCII: the panes are cut to size by counting characters
+	m.sprite = nil
+	url, err := pokeapi.SpriteURL(pokemon, "", false, false)
+	if err != nil {
+		return
+	}
+	data, err := sprite.Cache{Dir: filepath.Join(m.config.dir, "sprites")}.Get(url)
+	if err != nil {
+		return
+	}
+	img, err := sprite.Decode(data)
+	if err != nil {
+		return
+	}
+	var art strings.Builder
+	sprite.Render(&art, img, sprite.ASCII)
+	m.sprite = strings.Split(strings.TrimSuffix(art.String(), "\n"), "\n")
+}
+
+func (m *tuiModel) catch() {
+	if m.focus != paneEncounters || len(m.encounters) == 0 {
+		m.status = "select a Pokemon of the area to catch it"
+		return
+	}
+	name := m.encounters[m.encounterCursor]
+	m.loading(name)
+	result, err := m.config.catch(name)
+	switch {
+	case err != nil:
+		m.status = err.Error()
+	case !result.Caught:
+		m.status = name + " escaped!"
+	case result.Box > 0:
+		m.status = fmt.Sprintf("%s was caught and sent to box %d", name, result.Box)
+	default:
+		m.status = name + " was caught!"
+	}
+}
+
+// pane draws a list with a title, in exactly width x height.
+func (m *tuiModel) pane(title string, focus tuiPane, items []string, cursor int, width int, height int) []string {
+	heading := tui.Title(title, width)
+	if m.focus == focus {
+		heading = tui.Bold(heading)
+	}
+	lines := []string{heading}
+
+	top := tui.Scroll(cursor, len(items), height-1)
+	for i := top; i < len(items) && len(lines) < height; i++ {
+		line := tui.Fit(" "+items[i], width)
+		if i == cursor && m.focus == focus {
+			line = tui.Reverse(line)
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines
+}
+
+// detailLines describes the opened Pokemon.
+func (m *tuiModel) detailLines(width int) []string {
+	if m.detail == nil {
+		return []string{"", " Open a Pokemon to see it here."}
+	}
+	p := m.detail
+
+	types := []string{}
+	for _, t := range p.Types {
+		types = append(types, t.Type.Name)
+	}
+	lines := []string{
+		fmt.Sprintf(" #%03d %s  [%s]", p.ID, p.Name, strings.Join(types, "/")),
+		fmt.Sprintf(" %.1f m, %.1f kg", float64(p.Height)/10, float64(p.Weight)/10),
+		"",
+	}
+
+	barWidth := max(0, width-24)
+	total := 0
+	for _, s := range p.Stats {
+		total += s.BaseStat
+		bar := strings.Repeat("#", min(s.BaseStat, maxStat)*barWidth/maxStat)
+		lines = append(lines, fmt.Sprintf(" %-16s %3d %s", s.Stat.Name, s.BaseStat, bar))
+	}
+	lines = append(lines, fmt.Sprintf(" %-16s %3d", "total", total), "")
+
+	for _, line := range m.sprite {
+		lines = append(lines, " "+line)
+	}
+	return lines
+}
+
+func (m *tuiModel) view(width int, height int) []string {
+	width, height = max(width, 40), max(height, 10)
+
+	leftWidth := max(24, width/3)
+	rightWidth := width - leftWidth - 1
+	bodyHeight := height - 2
+
+	areasHeight := bodyHeight / 2
+	left := m.pane(fmt.Sprintf("Areas %d/%d", m.areas.Page, m.areas.Pages), paneAreas, m.areas.Areas, m.areaCursor, leftWidth, areasHeight)
+	encountersTitle := "Encounters"
+	if m.area != "" {
+		encountersTitle += " in " + m.area
+	}
+	left = append(left, m.pane(encountersTitle, paneEncounters, m.encounters, m.encounterCursor, leftWidth, bodyHeight-areasHeight)...)
+
+	collection := []string{}
+	party := len(m.config.save.Storage.Party)
+	for i, p := range m.config.save.Storage.All() {
+		place := "PC"
+		if i < party {
+			place = "party"
+		}
+		collection = append(collection, fmt.Sprintf("%-5s %s", place, caughtName(p)))
+	}
+	collectionHeight := bodyHeight / 3
+	detailHeight := bodyHeight - collectionHeight
+	right := []string{tui.Title("Pokemon", rightWidth)}
+	for _, line := range m.detailLines(rightWidth) {
+		if len(right) < detailHeight {
+			right = append(right, tui.Fit(line, rightWidth))
+		}
+	}
+	for len(right) < detailHeight {
+		right = append(right, strings.Repeat(" ", rightWidth))
+	}
+	right = append(right, m.pane(fmt.Sprintf("Caught %d", len(collection)), paneCollection, collection, m.collectionCursor, rightWidth, collectionHeight)...)
+
+	lines := []string{tui.Reverse(tui.Fit(" Pokedex", width))}
+	for i := 0; i < bodyHeight; i++ {
+		lines = append(lines, left[i]+"│"+right[i])
+	}
+	status := tuiHelp
+	if m.status != "" {
+		status = m.status
+	}
+	return append(lines, tui.Fit(" "+status, width))
+}
+
+// caughtName is "nickname (species)" or just the species.
+func caughtName(p pc.Pokemon) string {
+	if p.Nickname != "" {
+		return fmt.Sprintf("%s (%s)", p.Nickname, p.Name)
+	}
+	return p.Name
+}